@@ -0,0 +1,111 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// flagAliases are the short forms of common render flags.
+var flagAliases = map[string]string{
+	"o": "output",
+	"t": "title",
+	"y": "years",
+}
+
+// expandAliases rewrites the short flags in args to the flags they stand
+// for, so fs and everything that inspects it afterwards only ever sees
+// the full names. Like fs.Parse it stops at the first argument that
+// isn't a flag, and it leaves the values of flags alone.
+func expandAliases(fs *flag.FlagSet, args []string) []string {
+	out := append([]string(nil), args...)
+	for i := 0; i < len(out); i++ {
+		a := out[i]
+		if a == "--" || len(a) < 2 || a[0] != '-' {
+			break
+		}
+		dashes := "-"
+		if strings.HasPrefix(a, "--") {
+			dashes = "--"
+		}
+		name, value, hasValue := strings.Cut(a[len(dashes):], "=")
+		if full, ok := flagAliases[name]; ok && fs.Lookup(full) != nil {
+			name = full
+			out[i] = dashes + name
+			if hasValue {
+				out[i] += "=" + value
+			}
+		}
+		if f := fs.Lookup(name); f != nil && !hasValue && !isBoolFlag(f) {
+			i++ // the next argument is this flag's value
+		}
+	}
+	return out
+}
+
+// aliasesFor lists the short forms of fs's flags as "-t (-title)".
+func aliasesFor(fs *flag.FlagSet) []string {
+	var out []string
+	for short, full := range flagAliases {
+		if fs.Lookup(full) != nil {
+			out = append(out, fmt.Sprintf("-%s (-%s)", short, full))
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+// suggestFlag rewrites an unknown-flag error from fs to spell the flag
+// with as many dashes as args did and to name the closest flag, if one is
+// close enough. Other errors are returned as they are.
+func suggestFlag(fs *flag.FlagSet, args []string, err error) error {
+	const prefix = "flag provided but not defined: -"
+	msg := err.Error()
+	if !strings.HasPrefix(msg, prefix) {
+		return err
+	}
+	name := strings.TrimPrefix(msg, prefix)
+	dashes := "-"
+	for _, a := range args {
+		if a == "--"+name || strings.HasPrefix(a, "--"+name+"=") {
+			dashes = "--"
+			break
+		}
+	}
+	best, bestDist := "", 0
+	fs.VisitAll(func(f *flag.Flag) {
+		d := editDistance(name, f.Name)
+		if strings.HasPrefix(f.Name, name) {
+			d = min(d, 1)
+		}
+		if best == "" || d < bestDist {
+			best, bestDist = f.Name, d
+		}
+	})
+	if best == "" || bestDist > max(2, len(name)/3) {
+		return fmt.Errorf("unknown flag %s%s", dashes, name)
+	}
+	return fmt.Errorf("unknown flag %s%s; did you mean %s%s?", dashes, name, dashes, best)
+}
+
+// editDistance is the Levenshtein distance between a and b.
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+)
+
+func TestExpandAliases(t *testing.T) {
+	// Each group spells the same command line differently; all parse to
+	// the same flags and arguments.
+	for _, group := range [][][]string{
+		{
+			{"-t", "My life", "input.csv"},
+			{"--t", "My life", "input.csv"},
+			{"-title", "My life", "input.csv"},
+			{"--title", "My life", "input.csv"},
+		},
+		{
+			{"-title=My life", "input.csv"},
+			{"-title", "My life", "input.csv"},
+			{"-t=My life", "input.csv"},
+			{"--title=My life", "input.csv"},
+		},
+		{
+			{"-output", "out.svg", "input.csv"},
+			{"-o", "out.svg", "input.csv"},
+			{"-o=out.svg", "input.csv"},
+			{"--output=out.svg", "input.csv"},
+		},
+		{
+			{"-y", "-t", "-o", "input.csv"},
+			{"-years", "-title", "-o", "input.csv"},
+		},
+	} {
+		var first string
+		for i, args := range group {
+			f := newRenderFlags()
+			expanded := expandAliases(f.fs, args)
+			if err := parseFlags(f.fs, expanded); err != nil {
+				t.Errorf("%q: %v", args, err)
+				continue
+			}
+			got := fmt.Sprintf("title %q, output %q, years %v, args %q", *f.title, *f.output, f.chart.ShowYears, f.fs.Args())
+			if i == 0 {
+				first = got
+			} else if got != first {
+				t.Errorf("%q parses to %s, but %q to %s", args, got, group[0], first)
+			}
+		}
+	}
+
+	// Values and arguments after the flags are left as they are.
+	f := newRenderFlags()
+	args := []string{"-title", "-o", "-o", "-t", "input.csv", "-y"}
+	want := []string{"-title", "-o", "-output", "-t", "input.csv", "-y"}
+	if got := expandAliases(f.fs, args); !slices.Equal(got, want) {
+		t.Errorf("expandAliases(%q) = %q, want %q", args, got, want)
+	}
+}
+
+func TestSuggestFlag(t *testing.T) {
+	for _, tc := range []struct {
+		args []string
+		want string
+	}{
+		{[]string{"-titel", "x", "input.csv"}, "unknown flag -titel; did you mean -title?"},
+		{[]string{"--titel=x", "input.csv"}, "unknown flag --titel; did you mean --title?"},
+		{[]string{"-yeras", "input.csv"}, "unknown flag -yeras; did you mean -years?"},
+		{[]string{"-outptu", "x.png", "input.csv"}, "unknown flag -outptu; did you mean -output?"},
+		{[]string{"-split-by", "dir", "input.csv"}, "unknown flag -split-by; did you mean -split-by-category?"},
+		{[]string{"--zzzzzz", "input.csv"}, "unknown flag --zzzzzz"},
+	} {
+		f := newRenderFlags()
+		err := parseFlags(f.fs, expandAliases(f.fs, tc.args))
+		if err == nil || err.Error() != tc.want {
+			t.Errorf("%q: error %v, want %q", tc.args, err, tc.want)
+		}
+	}
+}
+
+func TestEditDistance(t *testing.T) {
+	for _, tc := range []struct {
+		a, b string
+		want int
+	}{
+		{"", "", 0},
+		{"title", "title", 0},
+		{"", "title", 5},
+		{"titel", "title", 2},
+		{"titl", "title", 1},
+		{"xtitle", "title", 1},
+		{"tilte", "title", 2},
+		{"colr", "color", 1},
+		{"kitten", "sitting", 3},
+	} {
+		if got := editDistance(tc.a, tc.b); got != tc.want {
+			t.Errorf("editDistance(%q, %q) = %d, want %d", tc.a, tc.b, got, tc.want)
+		}
+		if got := editDistance(tc.b, tc.a); got != tc.want {
+			t.Errorf("editDistance(%q, %q) = %d, want %d", tc.b, tc.a, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,267 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"unicode"
+
+	"github.com/rojaswestall/lifeline/internal/exit"
+)
+
+// batchFlags are the flags of the batch subcommand.
+type batchFlags struct {
+	fs     *flag.FlagSet
+	outDir *string
+	format *string
+	jobs   *int
+}
+
+// newBatchFlags defines the batch flags on a new FlagSet.
+func newBatchFlags() batchFlags {
+	fs := flag.NewFlagSet("batch", flag.ContinueOnError)
+	return batchFlags{
+		fs:     fs,
+		outDir: fs.String("out-dir", ".", "write each chart to `DIR`, named after its CSV"),
+		format: fs.String("format", "png", "image format of the charts: png or svg"),
+		jobs:   fs.Int("jobs", runtime.NumCPU(), "how many files to render at once"),
+	}
+}
+
+// batchJob is one file of a batch and the command line that renders it.
+type batchJob struct {
+	Input, Output string
+	Args          []string
+}
+
+// batchResult is how a batchJob went.
+type batchResult struct {
+	Index  int
+	Code   int
+	Output []byte // what the render printed
+	Err    error  // the render couldn't be started
+}
+
+// batchInputs expands patterns into the CSV files to render, in order and
+// without repeats: a directory stands for the CSVs in it, and anything
+// else is a glob, which may also be a plain file name.
+func batchInputs(patterns []string) ([]string, error) {
+	var out []string
+	seen := make(map[string]bool)
+	for _, pat := range patterns {
+		if fi, err := os.Stat(pat); err == nil && fi.IsDir() {
+			pat = filepath.Join(pat, "*.csv")
+		}
+		matches, err := filepath.Glob(pat)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", pat, err)
+		}
+		if len(matches) == 0 {
+			return nil, fmt.Errorf("%s: no such file", pat)
+		}
+		for _, m := range matches {
+			if !seen[m] {
+				seen[m] = true
+				out = append(out, m)
+			}
+		}
+	}
+	return out, nil
+}
+
+// readFlagsFile reads the render flags in a sidecar file such as
+// mom.csv.flags: whitespace-separated words, which may be quoted with ' or
+// ", on any number of lines, with # starting a comment. A missing file
+// has no flags.
+func readFlagsFile(path string) ([]string, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	var out []string
+	for n, line := range strings.Split(string(data), "\n") {
+		words, err := splitWords(line)
+		if err != nil {
+			return nil, fmt.Errorf("%s line %d: %w", path, n+1, err)
+		}
+		out = append(out, words...)
+	}
+	return out, nil
+}
+
+// splitWords splits a line into words the way a shell would for simple
+// cases: on whitespace, keeping quoted text together, up to a # that
+// starts a word.
+func splitWords(line string) ([]string, error) {
+	var words []string
+	var cur strings.Builder
+	inWord := false
+	var quote rune
+	for _, r := range line {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				cur.WriteRune(r)
+			}
+		case r == '"' || r == '\'':
+			quote, inWord = r, true
+		case unicode.IsSpace(r):
+			if inWord {
+				words = append(words, cur.String())
+				cur.Reset()
+				inWord = false
+			}
+		case r == '#' && !inWord:
+			return words, nil
+		default:
+			cur.WriteRune(r)
+			inWord = true
+		}
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated %c quote", quote)
+	}
+	if inWord {
+		words = append(words, cur.String())
+	}
+	return words, nil
+}
+
+// batchJobs works out the command line for each input: the shared render
+// flags, then those of its sidecar file so they take precedence, then the
+// input and its output in outDir.
+func batchJobs(inputs []string, shared []string, outDir, format string) ([]batchJob, error) {
+	var jobs []batchJob
+	byOutput := make(map[string]string)
+	for _, in := range inputs {
+		base := filepath.Base(in)
+		out := filepath.Join(outDir, strings.TrimSuffix(base, filepath.Ext(base))+"."+format)
+		if prev, ok := byOutput[out]; ok {
+			return nil, fmt.Errorf("%s and %s would both be drawn to %s", prev, in, out)
+		}
+		byOutput[out] = in
+		own, err := readFlagsFile(in + ".flags")
+		if err != nil {
+			return nil, err
+		}
+		args := append(append(append([]string(nil), shared...), own...), in, out)
+		jobs = append(jobs, batchJob{Input: in, Output: out, Args: args})
+	}
+	return jobs, nil
+}
+
+// runBatchJobs renders jobs with up to n at a time, each by running this
+// program on its command line, and calls done with each result as it
+// finishes.
+func runBatchJobs(jobs []batchJob, n int, done func(batchResult)) error {
+	self, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	results := make(chan batchResult)
+	slots := make(chan struct{}, n)
+	for i, job := range jobs {
+		go func() {
+			slots <- struct{}{}
+			defer func() { <-slots }()
+			out, err := exec.Command(self, job.Args...).CombinedOutput()
+			r := batchResult{Index: i, Output: out}
+			var exitErr *exec.ExitError
+			switch {
+			case errors.As(err, &exitErr):
+				r.Code = exitErr.ExitCode()
+			case err != nil:
+				r.Code, r.Err = exit.Render, err
+			}
+			results <- r
+		}()
+	}
+	for range jobs {
+		done(<-results)
+	}
+	return nil
+}
+
+// runBatch implements `lifeline batch [-out-dir DIR] [-format png|svg]
+// [-jobs N] pattern ... [-- render flags]`.
+func runBatch(args []string) (int, error) {
+	f := newBatchFlags()
+	var shared []string
+	for i, a := range args {
+		if a == "--" {
+			args, shared = args[:i], args[i+1:]
+			break
+		}
+	}
+	patterns, err := parseInterspersed(f.fs, args)
+	if err != nil {
+		return flagStatus(err)
+	}
+	if len(patterns) == 0 {
+		return exit.Usage, errors.New("batch needs at least one file, directory or glob")
+	}
+	if *f.format != "png" && *f.format != "svg" {
+		return exit.Usage, fmt.Errorf("invalid -format %q: use png or svg", *f.format)
+	}
+	if *f.jobs < 1 {
+		return exit.Usage, fmt.Errorf("invalid -jobs %d: must be at least 1", *f.jobs)
+	}
+
+	inputs, err := batchInputs(patterns)
+	if err != nil {
+		return exit.Input, err
+	}
+	jobs, err := batchJobs(inputs, shared, *f.outDir, *f.format)
+	if err != nil {
+		return exit.Input, err
+	}
+	if err := os.MkdirAll(*f.outDir, 0o755); err != nil {
+		return exit.Render, err
+	}
+
+	// Each render's output is printed in one piece once it finishes, so
+	// parallel renders don't interleave.
+	codes := make([]int, len(jobs))
+	err = runBatchJobs(jobs, *f.jobs, func(r batchResult) {
+		job := jobs[r.Index]
+		codes[r.Index] = r.Code
+		status := "ok"
+		if r.Code != exit.OK {
+			status = fmt.Sprintf("failed (exit status %d)", r.Code)
+		}
+		fmt.Fprintf(os.Stderr, "== %s -> %s: %s\n", job.Input, job.Output, status)
+		os.Stderr.Write(r.Output)
+		if r.Err != nil {
+			fmt.Fprintf(os.Stderr, "lifeline: %v\n", r.Err)
+		}
+	})
+	if err != nil {
+		return exit.Render, err
+	}
+
+	var failed []string
+	code := exit.OK
+	for i, c := range codes {
+		if c != exit.OK {
+			failed = append(failed, jobs[i].Input)
+			if code == exit.OK {
+				code = c
+			}
+		}
+	}
+	fmt.Fprintf(os.Stderr, "%d of %d file(s) rendered", len(jobs)-len(failed), len(jobs))
+	if len(failed) > 0 {
+		fmt.Fprintf(os.Stderr, "; failed: %s", strings.Join(failed, ", "))
+	}
+	fmt.Fprintln(os.Stderr)
+	return code, nil
+}
@@ -0,0 +1,387 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/rojaswestall/lifeline/internal/render"
+)
+
+// Completion scripts are generated from the FlagSets themselves, so a new
+// flag is completed as soon as it is defined. Only the fixed choices and
+// file types below have to be listed by hand.
+
+// flagChoices are the values flags with a fixed set of them accept.
+var flagChoices = map[string][]string{
+	"adjust":          {"spacing", "legacy"},
+	"aggregate":       {"year:mean", "year:median", "year:min", "year:max", "month:mean", "month:median", "month:min", "month:max"},
+	"bucket-label":    {"range", "top"},
+	"category-shapes": {"on", "off"},
+	"format":          {"png", "svg"},
+	"gap-style":       {"dotted", "break"},
+	"grid":            {"off", "horizontal", "vertical", "both"},
+	"grid-style":      {"solid", "dotted"},
+	"label-rule":      {"all", "tagged", "every=", "abs>="},
+	"line-dash":       {"solid", "dashed", "dotted", "dashdot"},
+	"order-repair":    {"squeeze", "push", "fail"},
+	"palette":         append(render.PaletteNames(), "list"),
+	"polarity-style":  {"off", "shape"},
+	"same-year-order": {"input", "value", "label"},
+	"split-format":    {"png", "svg"},
+	"title-align":     {"left", "center", "right"},
+	"y-range":         {"life", "auto"},
+}
+
+// flagFiles are the file extensions flags naming a file accept; an empty
+// list means a directory.
+var flagFiles = map[string][]string{
+	"band":              {"csv"},
+	"baseline":          {"csv"},
+	"chapters":          {"csv"},
+	"config":            {"yaml", "yml"},
+	"fix":               {"csv"},
+	"invert":            {"csv"},
+	"o":                 {"csv"},
+	"output":            {"png", "svg"},
+	"out-dir":           {},
+	"render":            {"png", "svg"},
+	"report":            {"md", "json"},
+	"split-by-category": {},
+}
+
+// subcommandFlags builds the FlagSet of each subcommand.
+var subcommandFlags = map[string]func() *flag.FlagSet{
+	"stats":  func() *flag.FlagSet { return newStatsFlags().fs },
+	"lint":   func() *flag.FlagSet { return newLintFlags().fs },
+	"merge":  func() *flag.FlagSet { return newMergeFlags().fs },
+	"diff":   func() *flag.FlagSet { return newDiffFlags().fs },
+	"init":   func() *flag.FlagSet { return newInitFlags().fs },
+	"batch":  func() *flag.FlagSet { return newBatchFlags().fs },
+	"prompt": func() *flag.FlagSet { return newPromptFlags().fs },
+}
+
+// shells are the shells `lifeline completion` writes scripts for.
+var shells = []string{"bash", "zsh", "fish"}
+
+// completionFlag is a flag as a completion script sees it.
+type completionFlag struct {
+	Name, Usage string
+	Bool        bool
+	Values      []string // fixed choices, if any
+	Files       []string // file extensions, if it names a file
+	Dir         bool     // names a directory
+}
+
+// completionCommand is the render command ("" when implied, "render"
+// when named), grid, which takes the render flags too, or a subcommand,
+// with its flags and the extensions of the files it takes as arguments.
+type completionCommand struct {
+	Name  string
+	Flags []completionFlag
+	Exts  []string
+}
+
+// completionFlags lists the flags of fs for completion.
+func completionFlags(fs *flag.FlagSet) []completionFlag {
+	var out []completionFlag
+	fs.VisitAll(func(f *flag.Flag) {
+		_, usage := flag.UnquoteUsage(f)
+		cf := completionFlag{Name: f.Name, Usage: usage, Bool: isBoolFlag(f), Values: flagChoices[f.Name]}
+		if exts, ok := flagFiles[f.Name]; ok {
+			cf.Files, cf.Dir = exts, len(exts) == 0
+		}
+		out = append(out, cf)
+	})
+	return out
+}
+
+// completionCommands describes the render command, whose flags are in
+// render, and every subcommand.
+func completionCommands(render *flag.FlagSet) []completionCommand {
+	cmds := []completionCommand{
+		{Name: "", Flags: completionFlags(render), Exts: []string{"csv", "png", "svg"}},
+		{Name: "render", Flags: completionFlags(render), Exts: []string{"csv", "png", "svg"}},
+		{Name: "grid", Flags: completionFlags(render), Exts: []string{"csv", "png", "svg"}},
+	}
+	var names []string
+	for name := range subcommandFlags {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		cmds = append(cmds, completionCommand{Name: name, Flags: completionFlags(subcommandFlags[name]()), Exts: []string{"csv"}})
+	}
+	return cmds
+}
+
+// subcommandNames are the words completed after `lifeline`.
+func subcommandNames() []string {
+	names := []string{"config", "completion", "grid", "help", "render"}
+	for name := range subcommandFlags {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// writeCompletion writes the completion script for shell, given the
+// render command's flags.
+func writeCompletion(w io.Writer, shell string, render *flag.FlagSet) error {
+	cmds := completionCommands(render)
+	switch shell {
+	case "bash":
+		writeBashCompletion(w, cmds)
+	case "zsh":
+		writeZshCompletion(w, cmds)
+	case "fish":
+		writeFishCompletion(w, cmds)
+	default:
+		return fmt.Errorf("unknown shell %q: use %s", shell, strings.Join(shells, ", "))
+	}
+	return nil
+}
+
+// writeBashCompletion writes a script for bash's complete builtin.
+func writeBashCompletion(w io.Writer, cmds []completionCommand) {
+	fmt.Fprintf(w, `# bash completion for lifeline. Load it with:
+#   source <(lifeline completion bash)
+
+# _lifeline_files adds directories and files with the extensions in $1
+# (separated by spaces) to the completions of $cur.
+_lifeline_files() {
+    local IFS=$'\n' ext
+    COMPREPLY+=($(compgen -d -- "$cur"))
+    for ext in $(echo "$1" | tr ' ' '\n'); do
+        COMPREPLY+=($(compgen -f -X "!*.$ext" -- "$cur"))
+    done
+}
+
+_lifeline() {
+    local cur=${COMP_WORDS[COMP_CWORD]} prev=${COMP_WORDS[COMP_CWORD-1]}
+    local cmd="" flags exts
+    if [[ $COMP_CWORD -gt 1 ]]; then
+        cmd=${COMP_WORDS[1]}
+    fi
+    case $cmd in
+`)
+	for _, c := range cmds[1:] {
+		fmt.Fprintf(w, "    %s) flags=%q; exts=%q ;;\n", c.Name, bashFlagWords(c), strings.Join(c.Exts, " "))
+	}
+	fmt.Fprintf(w, "    *) flags=%q; exts=%q ;;\n    esac\n\n    case $prev in\n", bashFlagWords(cmds[0]), strings.Join(cmds[0].Exts, " "))
+
+	// Flag values: fixed choices, files, or free text with nothing to offer.
+	seen := make(map[string]bool)
+	var free []string
+	for _, c := range cmds {
+		for _, f := range c.Flags {
+			if f.Bool || seen[f.Name] {
+				continue
+			}
+			seen[f.Name] = true
+			switch {
+			case len(f.Values) > 0:
+				fmt.Fprintf(w, "    -%s) COMPREPLY=($(compgen -W %q -- \"$cur\")); return ;;\n", f.Name, strings.Join(f.Values, " "))
+			case f.Dir:
+				fmt.Fprintf(w, "    -%s) COMPREPLY=($(compgen -d -- \"$cur\")); return ;;\n", f.Name)
+			case len(f.Files) > 0:
+				fmt.Fprintf(w, "    -%s) _lifeline_files %q; return ;;\n", f.Name, strings.Join(f.Files, " "))
+			default:
+				free = append(free, "-"+f.Name)
+			}
+		}
+	}
+	sort.Strings(free)
+	fmt.Fprintf(w, "    %s) return ;;\n    esac\n", strings.Join(free, "|"))
+
+	fmt.Fprintf(w, `
+    if [[ $cur == -* ]]; then
+        COMPREPLY=($(compgen -W "$flags" -- "$cur"))
+        return
+    fi
+    if [[ $COMP_CWORD -eq 1 ]]; then
+        COMPREPLY=($(compgen -W %q -- "$cur"))
+    fi
+    case $cmd in
+    config)
+        [[ $COMP_CWORD -eq 2 ]] && COMPREPLY=($(compgen -W "show" -- "$cur"))
+        return ;;
+    completion)
+        [[ $COMP_CWORD -eq 2 ]] && COMPREPLY=($(compgen -W %q -- "$cur"))
+        return ;;
+    help)
+        [[ $COMP_CWORD -eq 2 ]] && COMPREPLY=($(compgen -W %[1]q -- "$cur"))
+        return ;;
+    esac
+    _lifeline_files "$exts"
+}
+
+complete -o filenames -F _lifeline lifeline
+`, strings.Join(subcommandNames(), " "), strings.Join(shells, " "))
+}
+
+// bashFlagWords lists c's flags with their dashes.
+func bashFlagWords(c completionCommand) string {
+	words := make([]string, len(c.Flags))
+	for i, f := range c.Flags {
+		words[i] = "-" + f.Name
+	}
+	return strings.Join(words, " ")
+}
+
+// writeZshCompletion writes a script for zsh's completion system.
+func writeZshCompletion(w io.Writer, cmds []completionCommand) {
+	fmt.Fprintf(w, `#compdef lifeline
+# zsh completion for lifeline. Save it as _lifeline in a directory on
+# $fpath, or load it with:
+#   source <(lifeline completion zsh)
+
+_lifeline() {
+    local context state state_descr line
+    local -A opt_args
+    local -a render_flags
+    render_flags=(
+%s    )
+    if (( CURRENT > 2 )); then
+        local cmd=$words[2]
+        case $cmd in
+`, zshSpecs(cmds[0].Flags, "        ", false))
+	for _, c := range cmds[1:] {
+		fmt.Fprintf(w, "        %s)\n            shift words; (( CURRENT-- ))\n            _arguments \\\n%s                '*:file:_files -g %s'\n            return ;;\n",
+			c.Name, zshSpecs(c.Flags, "                ", true), zshGlob(c.Exts))
+	}
+	fmt.Fprintf(w, `        config)
+            shift words; (( CURRENT-- ))
+            _arguments $render_flags '1:subcommand:(show)'
+            return ;;
+        completion)
+            (( CURRENT == 3 )) && _values shell %[1]s
+            return ;;
+        help)
+            (( CURRENT == 3 )) && _values command %[3]s
+            return ;;
+        esac
+    fi
+    _arguments $render_flags \
+        '1: :->first' \
+        '2:output image:_files -g %[2]s'
+    if [[ $state == first ]]; then
+        _alternative \
+            'commands:command:(%[3]s)' \
+            'files:input file:_files -g %[4]s'
+    fi
+}
+
+if [[ $zsh_eval_context[-1] == loadautofunc ]]; then
+    _lifeline "$@"
+else
+    compdef _lifeline lifeline
+fi
+`, strings.Join(shells, " "), zshGlob([]string{"png", "svg"}), strings.Join(subcommandNames(), " "), zshGlob([]string{"csv"}))
+}
+
+// zshSpecs formats flags as _arguments specs, one quoted word per line
+// after indent, ending each line with a continuation when cont is set.
+func zshSpecs(flags []completionFlag, indent string, cont bool) string {
+	var b strings.Builder
+	for _, f := range flags {
+		desc := strings.NewReplacer(`\`, `\\`, "[", `\[`, "]", `\]`, "'", `'\''`).Replace(f.Usage)
+		spec := fmt.Sprintf("-%s[%s]", f.Name, desc)
+		switch {
+		case f.Bool:
+		case len(f.Values) > 0:
+			spec += fmt.Sprintf(":value:(%s)", strings.ReplaceAll(strings.Join(f.Values, " "), ":", `\:`))
+		case f.Dir:
+			spec += ":directory:_files -/"
+		case len(f.Files) > 0:
+			spec += ":file:_files -g " + zshGlob(f.Files)
+		default:
+			spec += ":value: "
+		}
+		fmt.Fprintf(&b, "%s'%s'", indent, spec)
+		if cont {
+			b.WriteString(" \\")
+		}
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+// zshGlob is a quoted glob matching the extensions.
+func zshGlob(exts []string) string {
+	if len(exts) == 1 {
+		return `"*.` + exts[0] + `"`
+	}
+	return `"*.(` + strings.Join(exts, "|") + `)"`
+}
+
+// writeFishCompletion writes a script of fish complete commands.
+func writeFishCompletion(w io.Writer, cmds []completionCommand) {
+	subs := strings.Join(subcommandNames(), " ")
+	fmt.Fprintf(w, `# fish completion for lifeline. Save it as
+# ~/.config/fish/completions/lifeline.fish, or load it with:
+#   lifeline completion fish | source
+
+complete -c lifeline -f
+complete -c lifeline -n __fish_use_subcommand -a %q
+complete -c lifeline -n '__fish_seen_subcommand_from config' -a show
+complete -c lifeline -n '__fish_seen_subcommand_from completion' -a %q
+complete -c lifeline -n '__fish_seen_subcommand_from help' -a %[1]q
+`, subs, strings.Join(shells, " "))
+	for _, c := range cmds {
+		cond := "__fish_seen_subcommand_from " + c.Name
+		if c.Name == "" {
+			// Render flags also apply to config show.
+			cond = "not __fish_seen_subcommand_from " + strings.Join(subcommandNamesExcept("config"), " ")
+		}
+		fmt.Fprintf(w, "\n# %s\n", fishTitle(c.Name))
+		fmt.Fprintf(w, "complete -c lifeline -n '%s' -a '%s'\n", cond, fishSuffixes(c.Exts))
+		for _, f := range c.Flags {
+			line := fmt.Sprintf("complete -c lifeline -n '%s' -o %s", cond, f.Name)
+			switch {
+			case f.Bool:
+			case len(f.Values) > 0:
+				line += fmt.Sprintf(" -x -a %q", strings.Join(f.Values, " "))
+			case f.Dir:
+				line += " -x -a '(__fish_complete_directories)'"
+			case len(f.Files) > 0:
+				line += fmt.Sprintf(" -r -a '%s'", fishSuffixes(f.Files))
+			default:
+				line += " -x"
+			}
+			desc := strings.NewReplacer(`\`, `\\`, "'", `\'`).Replace(f.Usage)
+			fmt.Fprintf(w, "%s -d '%s'\n", line, desc)
+		}
+	}
+}
+
+// fishSuffixes is a command substitution completing files with the
+// extensions.
+func fishSuffixes(exts []string) string {
+	calls := make([]string, len(exts))
+	for i, ext := range exts {
+		calls[i] = "__fish_complete_suffix ." + ext
+	}
+	return "(" + strings.Join(calls, "; ") + ")"
+}
+
+// subcommandNamesExcept is subcommandNames without name.
+func subcommandNamesExcept(name string) []string {
+	var out []string
+	for _, n := range subcommandNames() {
+		if n != name {
+			out = append(out, n)
+		}
+	}
+	return out
+}
+
+// fishTitle heads the lines for a command in the fish script.
+func fishTitle(name string) string {
+	if name == "" {
+		return "Rendering (and config show)"
+	}
+	return name
+}
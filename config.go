@@ -0,0 +1,317 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/rojaswestall/lifeline/internal/logging"
+)
+
+// stringList is a flag.Value collecting every use of a repeatable flag.
+type stringList []string
+
+func (l *stringList) String() string     { return strings.Join(*l, ", ") }
+func (l *stringList) Set(s string) error { *l = append(*l, s); return nil }
+
+// configEntry is one key of a config file and its value, or values for a
+// list such as callout.
+type configEntry struct {
+	Key    string
+	Values []string
+	List   bool // given as "- value" items
+	Line   int
+}
+
+// configPaths are searched in order for a config file when -config is
+// not given.
+func configPaths() []string {
+	paths := []string{"lifeline.yaml"}
+	if home, err := os.UserHomeDir(); err == nil {
+		paths = append(paths, filepath.Join(home, ".config", "lifeline", "config.yaml"))
+	}
+	return paths
+}
+
+// findConfig returns the config file to use: explicit if set, which must
+// exist, or else the first of configPaths that does. It returns "" when
+// there is none.
+func findConfig(explicit string) (string, error) {
+	if explicit != "" {
+		if _, err := os.Stat(explicit); err != nil {
+			return "", err
+		}
+		return explicit, nil
+	}
+	for _, p := range configPaths() {
+		if _, err := os.Stat(p); err == nil {
+			return p, nil
+		} else if !errors.Is(err, os.ErrNotExist) {
+			return "", err
+		}
+	}
+	return "", nil
+}
+
+// parseConfig reads the YAML subset config files use: "key: value" lines,
+// lists written as "key:" followed by indented "- value" lines, and #
+// comments. Values may be quoted with ' or ". Keys are flag names, with
+// or without a leading dash.
+func parseConfig(data string) ([]configEntry, error) {
+	var entries []configEntry
+	for n, raw := range strings.Split(data, "\n") {
+		line := strings.TrimRight(raw, "\r")
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		if item, ok := strings.CutPrefix(trimmed, "- "); ok {
+			if len(entries) == 0 {
+				return nil, fmt.Errorf("line %d: list item without a key", n+1)
+			}
+			e := &entries[len(entries)-1]
+			if len(e.Values) > 0 && !e.List {
+				return nil, fmt.Errorf("line %d: %s already has a value", n+1, e.Key)
+			}
+			v, err := configScalar(item)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", n+1, err)
+			}
+			e.Values = append(e.Values, v)
+			e.List = true
+			continue
+		}
+		if line != trimmed {
+			return nil, fmt.Errorf("line %d: unexpected indentation", n+1)
+		}
+		key, val, ok := strings.Cut(trimmed, ":")
+		if !ok {
+			return nil, fmt.Errorf("line %d: expected key: value", n+1)
+		}
+		key = strings.TrimLeft(strings.TrimSpace(key), "-")
+		e := configEntry{Key: key, Line: n + 1}
+		if val = strings.TrimSpace(val); val != "" && !strings.HasPrefix(val, "#") {
+			if strings.HasPrefix(val, "[") {
+				return nil, fmt.Errorf("line %d: write lists as indented \"- value\" lines", n+1)
+			}
+			v, err := configScalar(val)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", n+1, err)
+			}
+			e.Values = []string{v}
+		}
+		entries = append(entries, e)
+	}
+	for _, e := range entries {
+		if len(e.Values) == 0 {
+			return nil, fmt.Errorf("line %d: %s has no value", e.Line, e.Key)
+		}
+	}
+	return entries, nil
+}
+
+// configScalar unquotes a config value and drops a trailing comment from
+// an unquoted one.
+func configScalar(s string) (string, error) {
+	switch {
+	case strings.HasPrefix(s, `"`):
+		end := strings.LastIndex(s, `"`)
+		if end == 0 {
+			return "", fmt.Errorf("unterminated string %s", s)
+		}
+		return strconv.Unquote(s[:end+1])
+	case strings.HasPrefix(s, "'"):
+		end := strings.LastIndex(s, "'")
+		if end == 0 {
+			return "", fmt.Errorf("unterminated string %s", s)
+		}
+		return strings.ReplaceAll(s[1:end], "''", "'"), nil
+	}
+	if i := strings.Index(s, " #"); i >= 0 {
+		s = s[:i]
+	}
+	return strings.TrimSpace(s), nil
+}
+
+// isBoolFlag reports whether f is a switch such as -years.
+func isBoolFlag(f *flag.Flag) bool {
+	b, ok := f.Value.(interface{ IsBoolFlag() bool })
+	return ok && b.IsBoolFlag()
+}
+
+// applyConfig sets each flag named in entries unless skip has it, which
+// is how the command line and environment take precedence. It returns
+// the flags it set, and the entries that name no flag so the caller can
+// warn about them.
+func applyConfig(fs *flag.FlagSet, entries []configEntry, skip map[string]bool) (applied []string, unknown []configEntry, err error) {
+	for _, e := range entries {
+		f := fs.Lookup(e.Key)
+		if f == nil {
+			unknown = append(unknown, e)
+			continue
+		}
+		if skip[e.Key] {
+			continue
+		}
+		if err := setFlag(fs, f, e.Values); err != nil {
+			return applied, unknown, fmt.Errorf("line %d: %w", e.Line, err)
+		}
+		applied = append(applied, e.Key)
+	}
+	return applied, unknown, nil
+}
+
+// envPrefix starts the environment variables that set flags:
+// LIFELINE_TITLE for -title, LIFELINE_GAP_YEARS for -gap-years.
+const envPrefix = "LIFELINE_"
+
+// envName is the environment variable for the flag called name.
+func envName(name string) string {
+	return envPrefix + strings.ToUpper(strings.ReplaceAll(name, "-", "_"))
+}
+
+// setFlag sets a flag from a config file or the environment. Switches
+// also take yes/no and on/off, repeatable flags one value per element of
+// values. Errors read like those for a malformed command-line flag.
+func setFlag(fs *flag.FlagSet, f *flag.Flag, values []string) error {
+	for _, v := range values {
+		if isBoolFlag(f) {
+			switch strings.ToLower(v) {
+			case "yes", "on":
+				v = "true"
+			case "no", "off":
+				v = "false"
+			}
+		}
+		if err := fs.Set(f.Name, v); err != nil {
+			return fmt.Errorf("invalid value %q for flag -%s: %v", v, f.Name, err)
+		}
+	}
+	return nil
+}
+
+// applyEnv sets each flag of fs that has an environment variable, unless
+// skip has it. A repeatable flag takes one value per line of its
+// variable. It returns the flags it set, and the LIFELINE_ variables
+// that name no flag so the caller can warn about them.
+func applyEnv(fs *flag.FlagSet, environ []string, skip map[string]bool) (applied, unknown []string, err error) {
+	known := make(map[string]*flag.Flag)
+	fs.VisitAll(func(f *flag.Flag) { known[envName(f.Name)] = f })
+	for _, kv := range environ {
+		name, val, _ := strings.Cut(kv, "=")
+		if !strings.HasPrefix(name, envPrefix) {
+			continue
+		}
+		f := known[name]
+		if f == nil {
+			unknown = append(unknown, name)
+			continue
+		}
+		if skip[f.Name] {
+			continue
+		}
+		values := []string{val}
+		if _, ok := f.Value.(*stringList); ok {
+			values = strings.Split(strings.TrimSpace(val), "\n")
+		}
+		if err := setFlag(fs, f, values); err != nil {
+			return applied, unknown, fmt.Errorf("%s: %w", name, err)
+		}
+		applied = append(applied, f.Name)
+	}
+	sort.Strings(unknown)
+	return applied, unknown, nil
+}
+
+// loadConfig fills in the flags of fs that were not set on the command
+// line, first from LIFELINE_ environment variables and then from the
+// config file named by the config flag or found by findConfig, and warns
+// about settings that name no flag. It returns where each flag that isn't
+// at its default got its value, for config show.
+func loadConfig(fs *flag.FlagSet) (map[string]string, error) {
+	sources := make(map[string]string)
+	skip := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) {
+		sources[f.Name] = "command line"
+		skip[f.Name] = true
+	})
+
+	applied, unknown, err := applyEnv(fs, os.Environ(), skip)
+	if err != nil {
+		return sources, err
+	}
+	for _, name := range unknown {
+		logging.Warnf("environment variable %s does not name a flag", name)
+	}
+	for _, name := range applied {
+		sources[name] = "environment " + envName(name)
+		skip[name] = true
+	}
+
+	path, err := findConfig(fs.Lookup("config").Value.String())
+	if err != nil || path == "" {
+		return sources, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return sources, err
+	}
+	entries, err := parseConfig(string(data))
+	if err != nil {
+		return sources, fmt.Errorf("%s: %w", path, err)
+	}
+	skip["config"] = true
+	applied, unknownKeys, err := applyConfig(fs, entries, skip)
+	if err != nil {
+		return sources, fmt.Errorf("%s: %w", path, err)
+	}
+	for _, e := range unknownKeys {
+		logging.Warnf("%s line %d: unknown setting %q", path, e.Line, e.Key)
+	}
+	for _, name := range applied {
+		sources[name] = path
+	}
+	return sources, nil
+}
+
+// writeConfig prints every flag of fs as a config file, noting where each
+// value came from: sources maps flag names to a description such as
+// "command line", and the rest are defaults.
+func writeConfig(w io.Writer, fs *flag.FlagSet, sources map[string]string) {
+	var names []string
+	fs.VisitAll(func(f *flag.Flag) { names = append(names, f.Name) })
+	sort.Strings(names)
+	for _, name := range names {
+		f := fs.Lookup(name)
+		src := sources[name]
+		if src == "" {
+			src = "default"
+		}
+		if l, ok := f.Value.(*stringList); ok {
+			if len(*l) == 0 {
+				fmt.Fprintf(w, "# %s: (none)  # %s\n", name, src)
+				continue
+			}
+			fmt.Fprintf(w, "%s:  # %s\n", name, src)
+			for _, v := range *l {
+				fmt.Fprintf(w, "  - %s\n", configQuote(v))
+			}
+			continue
+		}
+		fmt.Fprintf(w, "%s: %s  # %s\n", name, configQuote(f.Value.String()), src)
+	}
+}
+
+// configQuote quotes v when it would not read back as the same string.
+func configQuote(v string) string {
+	if v == "" || strings.TrimSpace(v) != v || strings.ContainsAny(v, "#:\"'[{") {
+		return strconv.Quote(v)
+	}
+	return v
+}
@@ -0,0 +1,296 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"image/color"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gonum.org/v1/plot"
+	"gonum.org/v1/plot/plotter"
+	"gonum.org/v1/plot/vg"
+	"gonum.org/v1/plot/vg/draw"
+
+	"github.com/rojaswestall/lifeline/internal/exit"
+	"github.com/rojaswestall/lifeline/internal/render"
+	"github.com/rojaswestall/lifeline/internal/timeline"
+)
+
+// diffEvent is an event added or removed between two versions of a file.
+type diffEvent struct {
+	Year  float64 `json:"year"`
+	Value float64 `json:"value"`
+	Label string  `json:"label"`
+}
+
+// diffChange is an event present in both versions with a different value.
+type diffChange struct {
+	Year  float64 `json:"year"`
+	Label string  `json:"label"`
+	From  float64 `json:"from"`
+	To    float64 `json:"to"`
+}
+
+// eventDiff is the result of comparing two versions of an events file.
+type eventDiff struct {
+	Added     []diffEvent  `json:"added"`
+	Removed   []diffEvent  `json:"removed"`
+	Changed   []diffChange `json:"changed"`
+	Unchanged int          `json:"unchanged"`
+}
+
+// diffKey matches events across versions by year and label. Rows without
+// a label match any other unlabeled row in the same year, since their
+// generated label includes the value that may have changed.
+func diffKey(p timeline.Point) string {
+	if !p.Tagged {
+		return fmt.Sprintf("%g|", p.Year)
+	}
+	return fmt.Sprintf("%g|%s", p.Year, p.Label)
+}
+
+// diffPoints compares chronologically sorted versions of a timeline.
+// Events sharing a key are paired up in order; any left over on either
+// side count as added or removed.
+func diffPoints(before, after []timeline.Point) eventDiff {
+	d := eventDiff{Added: []diffEvent{}, Removed: []diffEvent{}, Changed: []diffChange{}}
+	ev := func(p timeline.Point) diffEvent { return diffEvent{Year: p.Year, Value: p.Value, Label: p.Label} }
+
+	pending := make(map[string][]timeline.Point)
+	for _, p := range before {
+		pending[diffKey(p)] = append(pending[diffKey(p)], p)
+	}
+	for _, p := range after {
+		k := diffKey(p)
+		if len(pending[k]) == 0 {
+			d.Added = append(d.Added, ev(p))
+			continue
+		}
+		o := pending[k][0]
+		pending[k] = pending[k][1:]
+		if o.Value != p.Value {
+			d.Changed = append(d.Changed, diffChange{Year: p.Year, Label: p.Label, From: o.Value, To: p.Value})
+		} else {
+			d.Unchanged++
+		}
+	}
+	for _, p := range before {
+		k := diffKey(p)
+		if len(pending[k]) > 0 && pending[k][0].Row == p.Row {
+			d.Removed = append(d.Removed, ev(p))
+			pending[k] = pending[k][1:]
+		}
+	}
+	return d
+}
+
+// changeArrows draws a vertical arrow from the old to the new value of
+// each changed event.
+type changeArrows struct {
+	Changes []diffChange
+	Color   color.Color
+}
+
+// Plot implements the plot.Plotter interface.
+func (a changeArrows) Plot(c draw.Canvas, plt *plot.Plot) {
+	trX, trY := plt.Transforms(&c)
+	sty := draw.LineStyle{Color: a.Color, Width: vg.Points(1.5)}
+	head := vg.Points(6)
+	for _, ch := range a.Changes {
+		x, y0, y1 := trX(ch.Year), trY(ch.From), trY(ch.To)
+		dir := vg.Length(1)
+		if y1 < y0 {
+			dir = -1
+		}
+		if math.Abs(float64(y1-y0)) <= float64(head) {
+			c.StrokeLine2(sty, x, y0, x, y1)
+			continue
+		}
+		c.StrokeLine2(sty, x, y0, x, y1-dir*head)
+		c.FillPolygon(a.Color, []vg.Point{
+			{X: x, Y: y1},
+			{X: x - head/2, Y: y1 - dir*head},
+			{X: x + head/2, Y: y1 - dir*head},
+		})
+	}
+}
+
+// Thumbnail implements the plot.Thumbnailer interface.
+func (a changeArrows) Thumbnail(c *draw.Canvas) {
+	x := (c.Min.X + c.Max.X) / 2
+	c.StrokeLine2(draw.LineStyle{Color: a.Color, Width: vg.Points(1.5)}, x, c.Min.Y, x, c.Max.Y)
+}
+
+// renderDiff draws the new version of the timeline, its unchanged events
+// in the line color, with added events in the palette's positive color,
+// removed ones as hollow markers in its negative color, and arrows from
+// old to new values for changed ones.
+// Years are plotted as written; no spacing adjustment is applied.
+func renderDiff(after []timeline.Point, d eventDiff, title, file string) error {
+	pal := render.Palettes["default"]
+	p := plot.New()
+	p.Title.Text = title
+	p.X.Label.Text = "Year"
+	p.Y.Tick.Label.Font.Size = 0
+	p.Y.Tick.Length = 0
+	p.Y.Color = color.RGBA{} // the zero line is the only horizontal axis
+
+	if len(after) > 1 {
+		xy := make(plotter.XYs, len(after))
+		for i, pt := range after {
+			xy[i] = plotter.XY{X: pt.Year, Y: pt.Value}
+		}
+		line, err := plotter.NewLine(xy)
+		if err != nil {
+			return err
+		}
+		line.Color = pal.Line
+		line.Width = vg.Points(2)
+		p.Add(line)
+	}
+
+	addScatter := func(xy plotter.XYs, name string, sty draw.GlyphStyle) error {
+		if len(xy) == 0 {
+			return nil
+		}
+		sc, err := plotter.NewScatter(xy)
+		if err != nil {
+			return err
+		}
+		sc.GlyphStyle = sty
+		p.Add(sc)
+		if name != "" {
+			p.Legend.Add(name, sc)
+		}
+		return nil
+	}
+	var kept, added, removed plotter.XYs
+	isAdded := make(map[string]int)
+	for _, e := range d.Added {
+		isAdded[fmt.Sprintf("%g|%g|%s", e.Year, e.Value, e.Label)]++
+		added = append(added, plotter.XY{X: e.Year, Y: e.Value})
+	}
+	for _, pt := range after {
+		if k := fmt.Sprintf("%g|%g|%s", pt.Year, pt.Value, pt.Label); isAdded[k] > 0 {
+			isAdded[k]--
+			continue
+		}
+		kept = append(kept, plotter.XY{X: pt.Year, Y: pt.Value})
+	}
+	for _, e := range d.Removed {
+		removed = append(removed, plotter.XY{X: e.Year, Y: e.Value})
+	}
+	r := vg.Points(4)
+	if err := addScatter(kept, "", draw.GlyphStyle{Color: pal.Line, Radius: r, Shape: draw.CircleGlyph{}}); err != nil {
+		return err
+	}
+	if err := addScatter(added, fmt.Sprintf("added (%d)", len(d.Added)), draw.GlyphStyle{Color: pal.Positive, Radius: r * 1.4, Shape: draw.CircleGlyph{}}); err != nil {
+		return err
+	}
+	if err := addScatter(removed, fmt.Sprintf("removed (%d)", len(d.Removed)), draw.GlyphStyle{Color: pal.Negative, Radius: r * 1.4, Shape: draw.RingGlyph{}}); err != nil {
+		return err
+	}
+	if len(d.Changed) > 0 {
+		arrows := changeArrows{Changes: d.Changed, Color: pal.Highlight}
+		p.Add(arrows)
+		p.Legend.Add(fmt.Sprintf("changed (%d)", len(d.Changed)), arrows)
+		for _, ch := range d.Changed {
+			p.Y.Min = math.Min(p.Y.Min, math.Min(ch.From, ch.To))
+			p.Y.Max = math.Max(p.Y.Max, math.Max(ch.From, ch.To))
+		}
+	}
+	p.Legend.Top = true
+
+	// Keep the usual life frame of at least -10..10, padded a little.
+	p.Y.Min = math.Floor(math.Min(p.Y.Min, -10) - 1)
+	p.Y.Max = math.Ceil(math.Max(p.Y.Max, 10) + 1)
+	p.X.Min, p.X.Max = math.Floor(p.X.Min)-1, math.Ceil(p.X.Max)+1
+	zero, err := plotter.NewLine(plotter.XYs{{X: p.X.Min, Y: 0}, {X: p.X.Max, Y: 0}})
+	if err != nil {
+		return err
+	}
+	zero.Color = color.Gray{Y: 200}
+	p.Add(zero)
+
+	switch ext := strings.ToLower(filepath.Ext(file)); ext {
+	case ".png", ".svg":
+	default:
+		return fmt.Errorf("unsupported output format %q (use .png or .svg)", ext)
+	}
+	return savePlot(p, 12*vg.Inch, 8*vg.Inch, 0, false, nil, file)
+}
+
+// diffFlags are the flags of the diff subcommand.
+type diffFlags struct {
+	fs         *flag.FlagSet
+	asJSON     *bool
+	renderPath *string
+}
+
+// newDiffFlags defines the diff flags on a new FlagSet.
+func newDiffFlags() diffFlags {
+	fs := flag.NewFlagSet("diff", flag.ContinueOnError)
+	return diffFlags{
+		fs:         fs,
+		asJSON:     fs.Bool("json", false, "print the differences as JSON"),
+		renderPath: fs.String("render", "", "also draw the differences to a PNG or SVG `FILE`"),
+	}
+}
+
+// runDiff implements `lifeline diff [-json] [-render out.png] old.csv new.csv`.
+func runDiff(args []string) (int, error) {
+	f := newDiffFlags()
+	fs := f.fs
+	files, err := parseInterspersed(fs, args)
+	if err != nil {
+		return flagStatus(err)
+	}
+	if len(files) != 2 {
+		return exit.Usage, errors.New("diff takes two input files")
+	}
+
+	before, err := timeline.Load(files[0])
+	if err != nil {
+		return exit.Input, fmt.Errorf("%s: %w", files[0], err)
+	}
+	after, err := timeline.Load(files[1])
+	if err != nil {
+		return exit.Input, fmt.Errorf("%s: %w", files[1], err)
+	}
+	d := diffPoints(before, after)
+
+	if *f.asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(d); err != nil {
+			return exit.Render, err
+		}
+	} else {
+		for _, e := range d.Removed {
+			fmt.Printf("- %g %q (%g)\n", e.Year, e.Label, e.Value)
+		}
+		for _, e := range d.Added {
+			fmt.Printf("+ %g %q (%g)\n", e.Year, e.Label, e.Value)
+		}
+		for _, c := range d.Changed {
+			fmt.Printf("~ %g %q: %g -> %g (%+g)\n", c.Year, c.Label, c.From, c.To, c.To-c.From)
+		}
+		fmt.Printf("%d added, %d removed, %d changed, %d unchanged\n", len(d.Added), len(d.Removed), len(d.Changed), d.Unchanged)
+	}
+
+	if *f.renderPath != "" {
+		title := fmt.Sprintf("Changes from %s to %s", filepath.Base(files[0]), filepath.Base(files[1]))
+		if err := renderDiff(after, d, title, *f.renderPath); err != nil {
+			return exit.Render, err
+		}
+		if !*f.asJSON {
+			fmt.Printf("Wrote %s\n", *f.renderPath)
+		}
+	}
+	return exit.OK, nil
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/rojaswestall/lifeline/internal/exit"
+)
+
+// parseFlags parses args with fs, which must use flag.ContinueOnError,
+// leaving the error message to main. On -h it prints the command's help
+// on stdout and returns flag.ErrHelp; an unknown flag's error suggests the
+// closest one.
+func parseFlags(fs *flag.FlagSet, args []string) error {
+	fs.SetOutput(io.Discard)
+	err := fs.Parse(args)
+	if errors.Is(err, flag.ErrHelp) {
+		fs.SetOutput(os.Stdout)
+		printUsage(fs)
+	} else if err != nil {
+		err = suggestFlag(fs, args, err)
+	}
+	fs.SetOutput(os.Stderr)
+	return err
+}
+
+// flagStatus is what run returns for a parseFlags error: success after
+// -h, a usage error otherwise.
+func flagStatus(err error) (int, error) {
+	if errors.Is(err, flag.ErrHelp) {
+		return exit.OK, nil
+	}
+	return exit.Usage, err
+}
+
+// synopses are the usage texts printed after usage errors, by subcommand;
+// "" is a render without the command name.
+var synopses = map[string]string{
+	"":           "[flags] input.csv [output.png]\n       %[1]s COMMAND [flags] [arguments]\nRun '%[1]s help' to list the commands and '%[1]s -h' the render flags.",
+	"render":     "render [flags] input.csv [output.png]",
+	"stats":      "stats [-json] [-auto-scale] [-fractional-as-month] input.csv",
+	"lint":       "lint [-range MIN:MAX] [-max-label N] [-outlier-k K] [-fix out.csv] input.csv",
+	"merge":      "merge [-update] -o out.csv input.csv ...",
+	"diff":       "diff [-json] [-render diff.png] old.csv new.csv",
+	"init":       "init [-force] [-interactive] my-life.csv",
+	"grid":       "grid [-cols N] [-names A,B,...] [-align-years] [flags] a.csv b.csv ... output.png",
+	"batch":      "batch [-out-dir DIR] [-format png|svg] [-jobs N] input.csv|DIR|GLOB ... [-- render flags]",
+	"config":     "config show [flags]",
+	"prompt":     "prompt [-range MIN:MAX] events.csv",
+	"completion": "completion bash|zsh|fish",
+	"help":       "help [command]",
+}
+
+// usageText is the usage text for the command line args, which start
+// after the program name.
+func usageText(args []string) string {
+	name := filepath.Base(os.Args[0])
+	sub := ""
+	if len(args) > 0 {
+		if _, ok := synopses[args[0]]; ok {
+			sub = args[0]
+		}
+	}
+	return fmt.Sprintf("usage: %[1]s "+synopses[sub], name)
+}
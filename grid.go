@@ -0,0 +1,75 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"math"
+	"path/filepath"
+	"strings"
+
+	"github.com/rojaswestall/lifeline/internal/render"
+)
+
+// gridNames are the panel titles: the -names list when given, otherwise
+// each input's file name without its extension.
+func gridNames(inputs []string, names string) ([]string, error) {
+	if names != "" {
+		out := strings.Split(names, ",")
+		if len(out) != len(inputs) {
+			return nil, fmt.Errorf("%d name(s) for %d input(s)", len(out), len(inputs))
+		}
+		for i := range out {
+			out[i] = strings.TrimSpace(out[i])
+		}
+		return out, nil
+	}
+	out := make([]string, len(inputs))
+	for i, in := range inputs {
+		base := filepath.Base(in)
+		out[i] = strings.TrimSuffix(base, filepath.Ext(base))
+	}
+	return out, nil
+}
+
+// gridCols is the number of columns for n panels: cols when set,
+// otherwise enough for a roughly square grid.
+func gridCols(n, cols int) int {
+	if cols > 0 {
+		return min(cols, n)
+	}
+	return int(math.Ceil(math.Sqrt(float64(n))))
+}
+
+// gridFrames gives each panel the y-range covering every panel's frame.
+// With alignX they share the x-range too; otherwise each keeps its own.
+func gridFrames(frames []render.Frame, alignX bool) []render.Frame {
+	all := frames[0]
+	for _, f := range frames[1:] {
+		all.XMin, all.XMax = math.Min(all.XMin, f.XMin), math.Max(all.XMax, f.XMax)
+		all.YMin, all.YMax = math.Min(all.YMin, f.YMin), math.Max(all.YMax, f.YMax)
+	}
+	out := make([]render.Frame, len(frames))
+	for i, f := range frames {
+		out[i] = all
+		if !alignX {
+			out[i].XMin, out[i].XMax = f.XMin, f.XMax
+		}
+	}
+	return out
+}
+
+// parseGridArgs parses the flags of a grid, which may come before, among
+// or after the input files, and returns the files.
+func parseGridArgs(fs *flag.FlagSet, args []string) ([]string, error) {
+	var files []string
+	for {
+		if err := parseFlags(fs, expandAliases(fs, args)); err != nil {
+			return nil, err
+		}
+		if fs.NArg() == 0 {
+			return files, nil
+		}
+		files = append(files, fs.Arg(0))
+		args = fs.Args()[1:]
+	}
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/rojaswestall/lifeline/internal/exit"
+)
+
+// commandSummaries describe each command in one line, in the order
+// `lifeline help` lists them.
+var commandSummaries = []struct{ Name, Summary string }{
+	{"render", "draw a chart from a CSV (the default when no command is given)"},
+	{"init", "write a starter CSV to edit"},
+	{"prompt", "add events to a CSV by answering questions"},
+	{"stats", "summarize a CSV without drawing it"},
+	{"lint", "check a CSV for mistakes"},
+	{"merge", "combine several CSVs into one"},
+	{"diff", "compare two versions of a CSV"},
+	{"batch", "render many CSVs at once"},
+	{"grid", "draw several CSVs side by side in one image"},
+	{"config", "show the settings a render would use"},
+	{"completion", "print a shell completion script"},
+	{"help", "describe a command and its flags"},
+}
+
+// commandSentence is the description of the command name as a sentence,
+// or "" for an unknown one.
+func commandSentence(name string) string {
+	for _, c := range commandSummaries {
+		if c.Name == name {
+			return strings.ToUpper(c.Summary[:1]) + c.Summary[1:] + "."
+		}
+	}
+	return ""
+}
+
+// writeHelp lists the commands.
+func writeHelp(w io.Writer) {
+	name := filepath.Base(os.Args[0])
+	fmt.Fprintf(w, "usage: %s [command] [flags] [arguments]\n\nCommands:\n", name)
+	for _, c := range commandSummaries {
+		fmt.Fprintf(w, "  %-11s %s\n", c.Name, c.Summary)
+	}
+	fmt.Fprintf(w, "\nWithout a command, %[1]s renders: '%[1]s in.csv out.png' is '%[1]s render in.csv out.png'.\n", name)
+	fmt.Fprintf(w, "Run '%[1]s help COMMAND' or '%[1]s COMMAND -h' for a command's flags.\n", name)
+}
+
+// printUsage writes the help of the command whose flags are fs to fs's
+// output: its usage line, what it does, and its flags.
+func printUsage(fs *flag.FlagSet) {
+	sub := fs.Name()
+	if _, ok := synopses[sub]; !ok {
+		sub = "render"
+	}
+	w := fs.Output()
+	fmt.Fprintln(w, usageText([]string{sub}))
+	if s := commandSentence(sub); s != "" {
+		fmt.Fprintf(w, "\n%s\n", s)
+	}
+	fmt.Fprintln(w, "\nFlags:")
+	fs.PrintDefaults()
+	if short := aliasesFor(fs); len(short) > 0 {
+		fmt.Fprintf(w, "\nShort forms: %s. Every flag may also be written with two dashes.\n", strings.Join(short, ", "))
+	}
+}
+
+// runHelp implements `lifeline help [command]`. Help for render, grid
+// and config, which share the render flags, is printed by run itself.
+func runHelp(args []string) (int, error) {
+	if len(args) == 0 {
+		writeHelp(os.Stdout)
+		return exit.OK, nil
+	}
+	if len(args) > 1 {
+		return exit.Usage, errors.New("help takes at most one command")
+	}
+	sub := args[0]
+	switch sub {
+	case "render":
+		return run([]string{"-h"})
+	case "grid":
+		return run([]string{"grid", "-h"})
+	case "config":
+		return run([]string{"config", "show", "-h"})
+	case "completion", "help":
+		fmt.Printf("%s\n\n%s\n", usageText([]string{sub}), commandSentence(sub))
+		return exit.OK, nil
+	}
+	newFlags, ok := subcommandFlags[sub]
+	if !ok {
+		return exit.Usage, fmt.Errorf("unknown command %q", sub)
+	}
+	fs := newFlags()
+	fs.SetOutput(os.Stdout)
+	printUsage(fs)
+	return exit.OK, nil
+}
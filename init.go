@@ -0,0 +1,138 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rojaswestall/lifeline/internal/exit"
+)
+
+// starterHeader explains the format at the top of files written by init.
+const starterHeader = `# A lifeline events file. Lines starting with # are comments.
+#
+# Columns:
+#   year      when it happened: 2014, 2014.5 for mid-2014, or a span
+#             such as 2010-2014
+#   value     how it felt, from -10 (worst) to 10 (best); 0 is neutral
+#   label     a short caption; start it with ! to highlight the event
+#   category  optional; events in one category share a marker color
+#
+# Events in the same year are drawn side by side in file order.
+# Check the file with:  lifeline lint %[1]s
+# Draw it with:         lifeline %[1]s %[2]s
+`
+
+// starterRow is a sample event, dated relative to the year of birth.
+type starterRow struct {
+	Age      int
+	Value    int
+	Label    string
+	Category string
+}
+
+// starterRows are the sample events: both signs, a highlight, and a pair
+// sharing a year.
+var starterRows = []starterRow{
+	{18, 6, "Finished school", "school"},
+	{19, -4, "Moved away from home", "family"},
+	{23, 8, "First real job", "work"},
+	{23, -3, "Long commute", "work"},
+	{27, 9, "!Met my partner", "family"},
+}
+
+// starterCSV returns the contents of a starter file. When born is set the
+// file starts with a birth row and the samples are dated from it;
+// otherwise they are dated from 1990.
+func starterCSV(path string, born int) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, starterHeader, filepath.Base(path), defaultOutput(filepath.Base(path)))
+	b.WriteString("year,value,label,category\n")
+	base := 1990
+	if born != 0 {
+		base = born
+		fmt.Fprintf(&b, "%d,0,Born,family\n", born)
+	}
+	for _, r := range starterRows {
+		fmt.Fprintf(&b, "%d,%d,%s,%s\n", base+r.Age, r.Value, r.Label, r.Category)
+	}
+	return b.String()
+}
+
+// askBirthYear prompts for a year of birth on out and reads it from in.
+// An empty answer skips it.
+func askBirthYear(in io.Reader, out io.Writer) (int, error) {
+	sc := bufio.NewScanner(in)
+	this := time.Now().Year()
+	for {
+		fmt.Fprint(out, "Year of birth (empty to skip): ")
+		if !sc.Scan() {
+			return 0, sc.Err()
+		}
+		s := strings.TrimSpace(sc.Text())
+		if s == "" {
+			return 0, nil
+		}
+		y, err := strconv.Atoi(s)
+		if err == nil && y > this-130 && y <= this {
+			return y, nil
+		}
+		fmt.Fprintf(out, "%q is not a year between %d and %d\n", s, this-129, this)
+	}
+}
+
+// initFlags are the flags of the init subcommand.
+type initFlags struct {
+	fs          *flag.FlagSet
+	force       *bool
+	interactive *bool
+}
+
+// newInitFlags defines the init flags on a new FlagSet.
+func newInitFlags() initFlags {
+	fs := flag.NewFlagSet("init", flag.ContinueOnError)
+	return initFlags{
+		fs:          fs,
+		force:       fs.Bool("force", false, "overwrite the file if it exists"),
+		interactive: fs.Bool("interactive", false, "ask for a year of birth and date the sample events from it"),
+	}
+}
+
+// runInit implements `lifeline init [-force] [-interactive] file.csv`.
+func runInit(args []string) (int, error) {
+	f := newInitFlags()
+	fs := f.fs
+	files, err := parseInterspersed(fs, args)
+	if err != nil {
+		return flagStatus(err)
+	}
+	if len(files) != 1 {
+		return exit.Usage, errors.New("init takes one file name")
+	}
+	path := files[0]
+
+	if _, err := os.Stat(path); err == nil && !*f.force {
+		return exit.Render, fmt.Errorf("%s already exists; use -force to overwrite it", path)
+	} else if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return exit.Render, err
+	}
+
+	born := 0
+	if *f.interactive {
+		if born, err = askBirthYear(os.Stdin, os.Stdout); err != nil {
+			return exit.Input, err
+		}
+	}
+	if err := os.WriteFile(path, []byte(starterCSV(path, born)), 0o644); err != nil {
+		return exit.Render, err
+	}
+	fmt.Printf("Wrote %s; edit the sample rows, then run: lifeline %s %s\n", path, path, defaultOutput(path))
+	return exit.OK, nil
+}
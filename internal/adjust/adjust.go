@@ -0,0 +1,252 @@
+// Package adjust moves the x positions of crowded points apart while
+// keeping them in chronological order.
+package adjust
+
+import (
+	"fmt"
+	"math"
+
+	"gonum.org/v1/plot/vg"
+
+	"github.com/rojaswestall/lifeline/internal/exit"
+	"github.com/rojaswestall/lifeline/internal/logging"
+	"github.com/rojaswestall/lifeline/internal/timeline"
+)
+
+// Options say how Positions spreads events along the x-axis.
+// Most follow the render flag of the same name.
+type Options struct {
+	Mode           string  // -adjust: spacing or legacy
+	Off            bool    // -no-adjust: only same-year events are moved
+	DensityWindow  float64 // 0 picks a window from the span
+	Weighted       bool    // -density-weighted
+	MaxCompression float64
+	OrderRepair    string
+	MinSpacing     string    // -min-spacing, as written
+	Canvas         vg.Length // the width of the canvas the points are for
+	Months         bool      // -fractional-as-month, for the log
+}
+
+// Result is where Positions put the events.
+type Result struct {
+	// Points are the events, in the same order, with PlotX set.
+	Points      []timeline.Point
+	Densities   []float64 // each event's density, as the adjustment saw it
+	Adjustments []Adjustment
+	// SameYear is set when every event shares one year, so they were
+	// spread evenly around it instead.
+	SameYear bool
+}
+
+// Positions works out the x position of each of pts, which are
+// sorted by year: events sharing a year are set slightly apart, and
+// unless o.Off is set crowded stretches are then widened at the expense
+// of sparse ones. pts itself is left as it is.
+func Positions(pts []timeline.Point, o Options) (Result, error) {
+	yearStr := func(y float64) string { return timeline.FormatYear(y, o.Months) }
+
+	// Calculate density-based scaling for better spacing
+	// Adjustments only move PlotX; Year stays as written in the CSV so
+	// labels and logs never show an adjusted year. From here on everything
+	// works from adjustedPoints, so no step pairs it with points by index.
+	adjustedPoints := make([]timeline.Point, len(pts))
+	copy(adjustedPoints, pts)
+	for i := range adjustedPoints {
+		adjustedPoints[i].PlotX = adjustedPoints[i].Year
+	}
+
+	// The step-by-step adjustment log only prints with -verbose.
+	logging.Logf("\n=== Point Adjustment Process ===\n")
+
+	// When every event shares one year there is no time axis to work with,
+	// so events are spaced one unit apart in file order around that year.
+	sameYear := len(adjustedPoints) > 1 && adjustedPoints[0].Year == adjustedPoints[len(adjustedPoints)-1].Year
+	if sameYear {
+		logging.Logf("All %d events are in %s: spacing them evenly in file order\n", len(adjustedPoints), yearStr(adjustedPoints[0].Year))
+		for i := range adjustedPoints {
+			adjustedPoints[i].PlotX = adjustedPoints[i].Year + float64(i) - float64(len(adjustedPoints)-1)/2
+		}
+	} else {
+		// First pass: handle same-year overlaps with small offsets. The points
+		// are sorted stably by year, so each year's events form one run in
+		// input order and get the same offsets on every run.
+		for i := 0; i < len(adjustedPoints); {
+			currentYear := adjustedPoints[i].Year
+			j := i
+			for j < len(adjustedPoints) && adjustedPoints[j].Year == currentYear {
+				j++
+			}
+			sameYearCount := j - i
+
+			// If there are multiple events in the same year, space them out
+			for eventIndex := 0; sameYearCount > 1 && eventIndex < sameYearCount; eventIndex++ {
+				// Add small decimal offset: -0.4, -0.2, 0.0, 0.2, 0.4, etc.
+				spacing := 0.2
+				totalOffset := float64(sameYearCount-1) * spacing / 2
+				newYear := currentYear - totalOffset + (float64(eventIndex) * spacing)
+				adjustedPoints[i+eventIndex].PlotX = newYear
+
+				// Log same-year adjustments
+				if newYear != currentYear {
+					logging.Logf("Same-year adjustment: '%s' (%s) plotted at x=%.1f (event %d of %d in year %s)\n",
+						adjustedPoints[i+eventIndex].Label, yearStr(currentYear), newYear, eventIndex+1, sameYearCount, yearStr(currentYear))
+				}
+			}
+			i = j
+		}
+	}
+
+	// Second pass: apply density-based scaling for better distribution
+	densityScaledPoints := make([]timeline.Point, len(adjustedPoints))
+	copy(densityScaledPoints, adjustedPoints)
+
+	// Calculate local density for each point within a window of a few
+	// years, widened on long timelines where three years is a sliver.
+	densityWindow := o.DensityWindow
+	if densityWindow == 0 && len(adjustedPoints) > 0 {
+		densityWindow = math.Max(3, 0.02*(adjustedPoints[len(adjustedPoints)-1].Year-adjustedPoints[0].Year))
+	}
+	densities := make([]float64, len(adjustedPoints))
+	weights := densityWeights(adjustedPoints, o.Weighted)
+
+	for i := 0; i < len(adjustedPoints); i++ {
+		count := 0.0
+		for j := 0; j < len(adjustedPoints); j++ {
+			if math.Abs(adjustedPoints[j].PlotX-adjustedPoints[i].PlotX) <= densityWindow {
+				count += weights[j]
+			}
+		}
+		densities[i] = count
+	}
+
+	// Apply cumulative scaling based on density with normalization
+	// With fewer than three points there is no spacing to redistribute.
+	if len(densityScaledPoints) > 2 && !sameYear && !o.Off && o.Mode == "legacy" {
+		minYear := adjustedPoints[0].PlotX
+		maxYear := adjustedPoints[len(adjustedPoints)-1].PlotX
+		totalRange := maxYear - minYear
+
+		// First, calculate all scaled distances
+		scaledDistances := make([]float64, len(adjustedPoints)-1)
+		minDistances := make([]float64, len(adjustedPoints)-1)
+
+		for i := 1; i < len(adjustedPoints); i++ {
+			// Distance to previous point
+			actualDistance := adjustedPoints[i].PlotX - adjustedPoints[i-1].PlotX
+
+			// Scale factor based on average density of the two points
+			avgDensity := (densities[i] + densities[i-1]) / 2
+			scaleFactor := 1.0 + (avgDensity-1.0)*1.5 // Amplify dense areas by up to 150%
+			// Weighted densities can fall below one around minor events.
+			scaleFactor = math.Max(scaleFactor, 0.1)
+
+			scaledDistances[i-1] = actualDistance * scaleFactor
+			// Sparse stretches pay for the amplification, but only so far.
+			minDistances[i-1] = o.MaxCompression * actualDistance
+		}
+
+		// Now normalize and apply positions within the original year range
+		densityScaledPoints[0].PlotX = minYear // Keep first point fixed
+		if gaps := fitGaps(scaledDistances, minDistances, totalRange); gaps != nil {
+			for i := 1; i < len(adjustedPoints); i++ {
+				densityScaledPoints[i].PlotX = densityScaledPoints[i-1].PlotX + gaps[i-1]
+			}
+		}
+
+		// Print density scaling info
+		logging.Logf("\n=== Density-Based Scaling Results ===\n")
+
+		// Ensure chronological order is maintained (fix any backwards movement)
+		xs := make([]float64, len(densityScaledPoints))
+		for i, pt := range densityScaledPoints {
+			xs[i] = pt.PlotX
+		}
+		gap := 0.1
+		if last := len(xs) - 1; last > 0 {
+			gap = math.Min(gap, totalRange/float64(last))
+		}
+		if err := repairOrder(xs, gap, maxYear, o.OrderRepair); err != nil {
+			return Result{}, exit.RenderError(fmt.Errorf("-order-repair %s: %w", o.OrderRepair, err))
+		}
+		for i := range densityScaledPoints {
+			densityScaledPoints[i].PlotX = xs[i]
+		}
+
+		// Show detailed density scaling for all points
+		for i := 0; i < len(adjustedPoints); i++ {
+			if adjustedPoints[i].Interpolated {
+				continue
+			}
+			beforeDensityYear := adjustedPoints[i].PlotX
+			afterDensityYear := densityScaledPoints[i].PlotX
+
+			if math.Abs(afterDensityYear-beforeDensityYear) > 0.1 {
+				logging.Logf("Density scaling: '%s' (%s) | x after same-year: %.1f -> after density: %.1f | Density: %.3g\n",
+					adjustedPoints[i].Label, yearStr(adjustedPoints[i].Year), beforeDensityYear, afterDensityYear, densities[i])
+			} else {
+				logging.Logf("No density change: '%s' (%s) | x=%.1f | Density: %.3g\n",
+					adjustedPoints[i].Label, yearStr(adjustedPoints[i].Year), afterDensityYear, densities[i])
+			}
+		}
+
+		logging.Logf("=== End Density Scaling ===\n")
+	}
+
+	// Spacing mode works in canvas units: convert the target gap into years
+	// using the planned plot width, then push crowded neighbours apart.
+	if len(densityScaledPoints) > 2 && !sameYear && !o.Off && o.Mode == "spacing" {
+		xs := make([]float64, len(adjustedPoints))
+		for i, pt := range adjustedPoints {
+			xs[i] = pt.PlotX
+		}
+		span := xs[len(xs)-1] - xs[0]
+		minSpacing, err := ParseSpacing(o.MinSpacing)
+		if err != nil {
+			return Result{}, exit.UsageErrorf("invalid -min-spacing: %v", err)
+		}
+		minGap := span * float64(minSpacing/(o.Canvas-vg.Points(20)))
+		spread, ok := spreadToMinGap(xs, minGap, o.MaxCompression)
+		if !ok {
+			logging.Warnf("%d points do not fit %s apart on a %.1fin canvas; spacing them evenly",
+				len(xs), o.MinSpacing, o.Canvas/vg.Inch)
+		}
+
+		logging.Logf("\n=== Spacing Adjustment (min %.2f years) ===\n", minGap)
+		for i := range densityScaledPoints {
+			densityScaledPoints[i].PlotX = spread[i]
+			if math.Abs(spread[i]-xs[i]) > 0.1 && !adjustedPoints[i].Interpolated {
+				logging.Logf("Spacing: '%s' (%s) | x after same-year: %.1f -> after spacing: %.1f\n",
+					adjustedPoints[i].Label, yearStr(adjustedPoints[i].Year), xs[i], spread[i])
+			}
+		}
+		logging.Logf("=== End Spacing Adjustment ===\n")
+	}
+
+	pass := "spacing"
+	if o.Mode == "legacy" {
+		pass = "density scaling"
+	}
+	adjustments := Explain(adjustedPoints, densityScaledPoints, densities, pass)
+
+	// Use density-scaled points as the final adjusted points
+	adjustedPoints = densityScaledPoints
+
+	// Report how much each gap of a year or more grew or shrank relative to
+	// its share of the timeline.
+	if len(adjustedPoints) > 2 && !sameYear && !o.Off {
+		var years, xs []float64
+		for _, pt := range timeline.Observed(adjustedPoints) {
+			years = append(years, pt.Year)
+			xs = append(xs, pt.PlotX)
+		}
+		logging.Logf("\n=== Gap Ratios (after / before) ===\n")
+		for i, r := range gapShares(years, xs) {
+			if years[i+1]-years[i] >= 1 && !math.IsNaN(r) {
+				logging.Logf("Gap %s -> %s: %.2f\n", yearStr(years[i]), yearStr(years[i+1]), r)
+			}
+		}
+		logging.Logf("=== End Gap Ratios ===\n")
+	}
+
+	return Result{Points: adjustedPoints, Densities: densities, Adjustments: adjustments, SameYear: sameYear}, nil
+}
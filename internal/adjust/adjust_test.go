@@ -0,0 +1,108 @@
+package adjust
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"testing"
+
+	"gonum.org/v1/plot/vg"
+
+	"github.com/rojaswestall/lifeline/internal/timeline"
+)
+
+// events returns points at years, sorted as Positions expects, labeled
+// with their year and index.
+func events(years ...float64) []timeline.Point {
+	pts := make([]timeline.Point, len(years))
+	for i, y := range years {
+		pts[i] = timeline.Point{Year: y, Value: float64(i%5 - 2), Label: fmt.Sprintf("event %d in %g", i, y), Tagged: true, Row: i + 1}
+	}
+	return pts
+}
+
+// options are the render defaults for mode.
+func options(mode string) Options {
+	return Options{Mode: mode, MaxCompression: 0.4, OrderRepair: "squeeze", MinSpacing: "18pt", Canvas: 12 * vg.Inch}
+}
+
+// clustered has ties, a dense cluster and long gaps, with single events
+// at both ends.
+var clustered = []float64{1980, 1991, 1995, 1995, 1995, 1996, 1996.5, 1997, 1997, 1998, 2004, 2005, 2005, 2022}
+
+func TestPositionsKeepEnds(t *testing.T) {
+	for _, mode := range []string{"legacy", "spacing"} {
+		t.Run(mode, func(t *testing.T) {
+			pts := events(clustered...)
+			res, err := Positions(pts, options(mode))
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := res.Points
+			if first, last := got[0].PlotX, got[len(got)-1].PlotX; first != 1980 || last != 2022 {
+				t.Errorf("adjusted ends are %g and %g, want 1980 and 2022", first, last)
+			}
+			if Moved(res.Adjustments) == 0 {
+				t.Errorf("nothing moved, so the test checks nothing")
+			}
+		})
+	}
+}
+
+func TestPositionsFewPoints(t *testing.T) {
+	for _, years := range [][]float64{{2010}, {2010, 2014}, {2010, 2010}} {
+		for _, mode := range []string{"legacy", "spacing"} {
+			for _, off := range []bool{false, true} {
+				name := fmt.Sprintf("%v %s off=%v", years, mode, off)
+				o := options(mode)
+				o.Off = off
+				res, err := Positions(events(years...), o)
+				if err != nil {
+					t.Errorf("%s: %v", name, err)
+					continue
+				}
+				for i, p := range res.Points {
+					if math.IsNaN(p.PlotX) || math.IsInf(p.PlotX, 0) || math.IsNaN(res.Densities[i]) {
+						t.Errorf("%s: point %d at x=%g with density %g", name, i, p.PlotX, res.Densities[i])
+					}
+					if i > 0 && p.PlotX <= res.Points[i-1].PlotX {
+						t.Errorf("%s: point %d at x=%g is not after %g", name, i, p.PlotX, res.Points[i-1].PlotX)
+					}
+				}
+				if len(years) == 1 && res.Points[0].PlotX != years[0] {
+					t.Errorf("%s: a lone point moved to %g", name, res.Points[0].PlotX)
+				}
+			}
+		}
+	}
+}
+
+// hashPositions is a digest of the adjusted x of every point, by row.
+func hashPositions(pts []timeline.Point) string {
+	h := sha256.New()
+	for _, p := range pts {
+		fmt.Fprintf(h, "%d %.6f\n", p.Row, p.PlotX)
+	}
+	return hex.EncodeToString(h.Sum(nil))[:16]
+}
+
+func TestPositionsGolden(t *testing.T) {
+	// The digests change only when the adjusted positions do. If that is
+	// intended, update them from the test failure.
+	golden := map[string]string{
+		"legacy":  "6eee66302728ca50",
+		"spacing": "4af304fb14595608",
+	}
+	for mode, want := range golden {
+		for run := range 3 {
+			res, err := Positions(events(clustered...), options(mode))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := hashPositions(res.Points); got != want {
+				t.Errorf("%s, run %d: positions hash to %s, want %s", mode, run+1, got, want)
+			}
+		}
+	}
+}
@@ -0,0 +1,25 @@
+package adjust
+
+import "github.com/rojaswestall/lifeline/internal/timeline"
+
+// densityWeights returns each point's contribution to the density count:
+// its importance relative to the average, so a typical event still counts
+// about one. Without weighting every point counts exactly one.
+func densityWeights(pts []timeline.Point, weighted bool) []float64 {
+	w := make([]float64, len(pts))
+	sum := 0.0
+	for i, p := range pts {
+		w[i] = 1
+		if weighted {
+			w[i] = p.Importance
+		}
+		sum += w[i]
+	}
+	if weighted && sum > 0 {
+		mean := sum / float64(len(pts))
+		for i := range w {
+			w[i] /= mean
+		}
+	}
+	return w
+}
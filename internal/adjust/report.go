@@ -0,0 +1,104 @@
+package adjust
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/rojaswestall/lifeline/internal/logging"
+	"github.com/rojaswestall/lifeline/internal/timeline"
+)
+
+// Log writes an adjustment record for each point of the chart
+// drawn to output, with -log-json.
+func Log(output string, adj []Adjustment) {
+	if !logging.JSONRecords() {
+		return
+	}
+	for i := range adj {
+		logging.Emit(logging.Record{Event: "adjustment", Output: output, Adjustment: &adj[i]})
+	}
+}
+
+// Adjustment records how one point's x position was worked out, for the
+// -report file.
+type Adjustment struct {
+	Label     string   `json:"label"`
+	Year      float64  `json:"year"`
+	SameYearX float64  `json:"same_year_x"`
+	Density   float64  `json:"density"`
+	FinalX    float64  `json:"final_x"`
+	Reasons   []string `json:"reasons"`
+}
+
+// Explain pairs each point with its position after the
+// same-year pass (sameYear) and after spacing or density scaling (final).
+// pass names that second pass for the reasons.
+func Explain(sameYear, final []timeline.Point, densities []float64, pass string) []Adjustment {
+	const eps = 0.005
+	adj := make([]Adjustment, len(final))
+	for i, p := range final {
+		a := Adjustment{
+			Label:     p.Label,
+			Year:      p.Year,
+			SameYearX: sameYear[i].PlotX,
+			Density:   densities[i],
+			FinalX:    p.PlotX,
+			Reasons:   []string{},
+		}
+		switch {
+		case p.Interpolated:
+			a.Reasons = append(a.Reasons, "interpolated point")
+		case p.ZeroFilled:
+			a.Reasons = append(a.Reasons, "zero-filled point")
+		}
+		if math.Abs(a.SameYearX-a.Year) > eps {
+			a.Reasons = append(a.Reasons, "shares its year with other events")
+		}
+		if math.Abs(a.FinalX-a.SameYearX) > eps {
+			a.Reasons = append(a.Reasons, pass)
+		}
+		adj[i] = a
+	}
+	return adj
+}
+
+// Moved counts the points that were plotted away from their year.
+func Moved(adj []Adjustment) int {
+	moved := 0
+	for _, a := range adj {
+		if math.Abs(a.FinalX-a.Year) > 0.005 {
+			moved++
+		}
+	}
+	return moved
+}
+
+// WriteReport writes adj as a JSON array or, for any other
+// extension, a Markdown table.
+func WriteReport(path string, adj []Adjustment) error {
+	var b strings.Builder
+	if strings.ToLower(filepath.Ext(path)) == ".json" {
+		out, err := json.MarshalIndent(adj, "", "  ")
+		if err != nil {
+			return err
+		}
+		b.Write(out)
+		b.WriteByte('\n')
+	} else {
+		b.WriteString("| Label | Year | Same-year x | Density | Final x | Reasons |\n")
+		b.WriteString("|---|---|---|---|---|---|\n")
+		for _, a := range adj {
+			reasons := strings.Join(a.Reasons, "; ")
+			if reasons == "" {
+				reasons = "unchanged"
+			}
+			fmt.Fprintf(&b, "| %s | %g | %.2f | %.3g | %.2f | %s |\n",
+				strings.ReplaceAll(a.Label, "|", `\|`), a.Year, a.SameYearX, a.Density, a.FinalX, reasons)
+		}
+	}
+	return os.WriteFile(path, []byte(b.String()), 0o644)
+}
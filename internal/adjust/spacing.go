@@ -0,0 +1,173 @@
+package adjust
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"gonum.org/v1/plot/vg"
+)
+
+// ParseSpacing parses the -min-spacing flag: a length in points, with or
+// without a "pt" suffix (e.g. "18pt" or "18").
+func ParseSpacing(s string) (vg.Length, error) {
+	v, err := strconv.ParseFloat(strings.TrimSuffix(strings.TrimSpace(s), "pt"), 64)
+	if err != nil || v < 0 {
+		return 0, fmt.Errorf("%q: use a non-negative length in points such as 18pt", s)
+	}
+	return vg.Points(v), nil
+}
+
+// fitGaps scales want by a common factor, raising each gap to its floor
+// where needed, so that the gaps add up to span. The floors must not add up
+// to more than span. It returns nil when want is all zero.
+func fitGaps(want, floor []float64, span float64) []float64 {
+	sumWant := 0.0
+	for _, w := range want {
+		sumWant += w
+	}
+	if sumWant <= 0 {
+		return nil
+	}
+	// total(s) grows with s; total(0) <= span <= total(hi), so bisect for
+	// the scale at which the gaps exactly fill the span.
+	total := func(s float64) float64 {
+		sum := 0.0
+		for i, w := range want {
+			sum += max(w*s, floor[i])
+		}
+		return sum
+	}
+	lo, hi := 0.0, span/sumWant
+	for range 60 {
+		mid := (lo + hi) / 2
+		if total(mid) < span {
+			lo = mid
+		} else {
+			hi = mid
+		}
+	}
+	gaps := make([]float64, len(want))
+	for i, w := range want {
+		gaps[i] = max(w*hi, floor[i])
+	}
+	return gaps
+}
+
+// spreadToMinGap redistributes ascending xs over their original span so that
+// no two neighbours are closer than minGap. Gaps that are already wide
+// shrink by a common factor to pay for the narrow ones, which keeps their
+// proportions, but never below keep times their original width; the first
+// and last positions stay fixed. When minGap and keep cannot both be met,
+// keep is dropped. If the span is too short to honour minGap everywhere, the
+// points are spaced evenly and ok is false.
+func spreadToMinGap(xs []float64, minGap, keep float64) (out []float64, ok bool) {
+	out = append([]float64(nil), xs...)
+	n := len(xs)
+	if n < 3 || minGap <= 0 {
+		return out, n < 2 || minGap <= 0 || xs[n-1]-xs[0] >= minGap
+	}
+	span := xs[n-1] - xs[0]
+	if float64(n-1)*minGap >= span {
+		for i := range out {
+			out[i] = xs[0] + span*float64(i)/float64(n-1)
+		}
+		return out, false
+	}
+
+	want := make([]float64, n-1)
+	floor := make([]float64, n-1)
+	sumFloor := 0.0
+	for i := range want {
+		want[i] = xs[i+1] - xs[i]
+		floor[i] = max(minGap, keep*want[i])
+		sumFloor += floor[i]
+	}
+	if sumFloor > span {
+		for i := range floor {
+			floor[i] = minGap
+		}
+	}
+	gaps := fitGaps(want, floor, span)
+	for i := 1; i < n; i++ {
+		out[i] = out[i-1] + gaps[i-1]
+	}
+	out[n-1] = xs[n-1]
+	return out, true
+}
+
+// gapShares compares each gap between consecutive events after adjustment
+// with its share of the timeline before: 1 means the gap kept its
+// proportional width, 0.5 that it was squeezed to half. Gaps between events
+// in the same year are reported as NaN.
+func gapShares(years, xs []float64) []float64 {
+	n := len(years)
+	shares := make([]float64, max(n-1, 0))
+	spanYears, spanX := years[n-1]-years[0], xs[n-1]-xs[0]
+	for i := range shares {
+		dy := years[i+1] - years[i]
+		if dy == 0 || spanYears == 0 || spanX == 0 {
+			shares[i] = math.NaN()
+			continue
+		}
+		shares[i] = ((xs[i+1] - xs[i]) / spanX) / (dy / spanYears)
+	}
+	return shares
+}
+
+// repairOrder makes xs strictly increasing after density scaling, which
+// can leave a point at or before its predecessor. The first point never
+// moves. Strategies:
+//
+//   - push bumps each offending point to gap after the previous one. Long
+//     runs can then spill past the original last position.
+//   - squeeze spreads each offending run evenly between the last point
+//     before it and the next point that is already in order, with the last
+//     point pinned at end, so the range is never exceeded.
+//   - fail returns an error naming the first offending point.
+func repairOrder(xs []float64, gap, end float64, strategy string) error {
+	n := len(xs)
+	switch strategy {
+	case "push":
+		for i := 1; i < n; i++ {
+			if xs[i] <= xs[i-1] {
+				xs[i] = xs[i-1] + gap
+			}
+		}
+	case "squeeze":
+		if n > 1 {
+			xs[n-1] = end
+		}
+		for i := 1; i < n; i++ {
+			if xs[i] > xs[i-1] {
+				continue
+			}
+			s := i - 1
+			e := i + 1
+			for e < n-1 && xs[e] <= xs[s] {
+				e++
+			}
+			if e >= n {
+				e = n - 1
+			}
+			// A point scaled past the end has to join the run as well.
+			for s > 0 && xs[s] >= xs[e] {
+				s--
+			}
+			for k := s + 1; k < e; k++ {
+				xs[k] = xs[s] + (xs[e]-xs[s])*float64(k-s)/float64(e-s)
+			}
+			i = e
+		}
+	case "fail":
+		for i := 1; i < n; i++ {
+			if xs[i] <= xs[i-1] {
+				return fmt.Errorf("point %d lands at x=%.2f, not after the previous point at %.2f", i+1, xs[i], xs[i-1])
+			}
+		}
+	default:
+		return fmt.Errorf("%q: use push, squeeze or fail", strategy)
+	}
+	return nil
+}
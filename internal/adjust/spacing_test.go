@@ -0,0 +1,82 @@
+package adjust
+
+import (
+	"math"
+	"testing"
+)
+
+func TestFitGapsFillsSpan(t *testing.T) {
+	want := []float64{0.2, 0.2, 9, 0.4, 6}
+	floor := []float64{0.1, 0.1, 3.6, 0.2, 2.4}
+	const span = 16.0
+	gaps := fitGaps(want, floor, span)
+	sum := 0.0
+	for i, g := range gaps {
+		if g < floor[i] {
+			t.Errorf("gap %d is %g, below its floor %g", i, g, floor[i])
+		}
+		sum += g
+	}
+	if math.Abs(sum-span) > 1e-9 {
+		t.Errorf("gaps add up to %g, want %g", sum, span)
+	}
+}
+
+func TestRepairOrderSqueezeKeepsEnds(t *testing.T) {
+	for _, xs := range [][]float64{
+		{2000, 2003, 2002.5, 2002.8, 2009.6},     // last point short of the end
+		{2000, 2004, 2010.4, 2001, 2012},         // last point past the end
+		{2000, 2009.8, 2009.9, 2009.95, 2009.99}, // a run crowding the end
+	} {
+		xs = append([]float64(nil), xs...)
+		first := xs[0]
+		if err := repairOrder(xs, 0.1, 2010, "squeeze"); err != nil {
+			t.Fatal(err)
+		}
+		if xs[0] != first || xs[len(xs)-1] != 2010 {
+			t.Errorf("ends at %g and %g, want %g and 2010", xs[0], xs[len(xs)-1], first)
+		}
+		for i := 1; i < len(xs); i++ {
+			if xs[i] <= xs[i-1] {
+				t.Errorf("%v is not increasing at %d", xs, i)
+			}
+		}
+	}
+}
+
+func TestRepairOrderModes(t *testing.T) {
+	// A dense cluster scaled up against the end, the case that used to
+	// push points past it.
+	cluster := []float64{2000, 2009.9, 2009.5, 2009.6, 2009.7, 2009.8, 2010}
+	for _, tc := range []struct {
+		mode    string
+		err     string
+		pastEnd bool // whether the repaired points may run past the end
+	}{
+		{mode: "push", pastEnd: true},
+		{mode: "squeeze"},
+		{mode: "fail", err: "point 3 lands at x=2009.50, not after the previous point at 2009.90"},
+		{mode: "shift", err: `"shift": use push, squeeze or fail`},
+	} {
+		xs := append([]float64(nil), cluster...)
+		err := repairOrder(xs, 0.1, 2010, tc.mode)
+		if tc.err != "" {
+			if err == nil || err.Error() != tc.err {
+				t.Errorf("%s: error %v, want %q", tc.mode, err, tc.err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", tc.mode, err)
+			continue
+		}
+		for i := 1; i < len(xs); i++ {
+			if xs[i] <= xs[i-1] {
+				t.Errorf("%s: %v is not increasing at %d", tc.mode, xs, i)
+			}
+		}
+		if last := xs[len(xs)-1]; last > 2010 != tc.pastEnd {
+			t.Errorf("%s: last point at %g, end 2010", tc.mode, last)
+		}
+	}
+}
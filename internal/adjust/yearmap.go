@@ -0,0 +1,73 @@
+package adjust
+
+import (
+	"sort"
+
+	"github.com/rojaswestall/lifeline/internal/timeline"
+)
+
+// YearMap converts original years into plotted x positions after the
+// same-year and density adjustments. Between events it interpolates
+// linearly; outside the data it extends with a slope of one year per year.
+type YearMap struct {
+	years []float64 // original years, ascending and unique
+	xs    []float64 // plotted x for each entry in years
+}
+
+// NewYearMap builds a YearMap from adjusted points sorted by year.
+// Events sharing a year collapse to the mean of their plotted positions.
+func NewYearMap(pts []timeline.Point) YearMap {
+	var m YearMap
+	for i := 0; i < len(pts); {
+		j := i
+		sum := 0.0
+		for j < len(pts) && pts[j].Year == pts[i].Year {
+			sum += pts[j].PlotX
+			j++
+		}
+		m.years = append(m.years, pts[i].Year)
+		m.xs = append(m.xs, sum/float64(j-i))
+		i = j
+	}
+	return m
+}
+
+// X returns the plotted x position of the given original year.
+func (m YearMap) X(year float64) float64 {
+	n := len(m.years)
+	if n == 0 {
+		return year
+	}
+	if year <= m.years[0] {
+		return m.xs[0] - (m.years[0] - year)
+	}
+	if year >= m.years[n-1] {
+		return m.xs[n-1] + (year - m.years[n-1])
+	}
+	i := sort.SearchFloat64s(m.years, year)
+	if m.years[i] == year {
+		return m.xs[i]
+	}
+	t := (year - m.years[i-1]) / (m.years[i] - m.years[i-1])
+	return m.xs[i-1] + t*(m.xs[i]-m.xs[i-1])
+}
+
+// Year is the inverse of X: it returns the original year plotted at x.
+func (m YearMap) Year(x float64) float64 {
+	n := len(m.xs)
+	if n == 0 {
+		return x
+	}
+	if x <= m.xs[0] {
+		return m.years[0] - (m.xs[0] - x)
+	}
+	if x >= m.xs[n-1] {
+		return m.years[n-1] + (x - m.xs[n-1])
+	}
+	i := sort.SearchFloat64s(m.xs, x)
+	if m.xs[i] == x {
+		return m.years[i]
+	}
+	t := (x - m.xs[i-1]) / (m.xs[i] - m.xs[i-1])
+	return m.years[i-1] + t*(m.years[i]-m.years[i-1])
+}
@@ -0,0 +1,52 @@
+// Package exit defines the process exit codes and the errors that carry
+// them.
+package exit
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Exit statuses, so scripts can tell what kind of failure they hit.
+const (
+	OK     = 0
+	Usage  = 1 // bad flags or arguments; the usage text follows the error
+	Input  = 2 // an input file couldn't be read or its data is unusable
+	Render = 3 // the chart couldn't be drawn or written
+)
+
+// Error ties an error to the exit status it should end the run with,
+// for errors raised where only an error can be returned.
+type Error struct {
+	Code int
+	Err  error
+}
+
+func (e *Error) Error() string { return e.Err.Error() }
+
+func (e *Error) Unwrap() error { return e.Err }
+
+// UsageErrorf reports a mistake in the flags or arguments.
+func UsageErrorf(format string, a ...any) error {
+	return &Error{Usage, fmt.Errorf(format, a...)}
+}
+
+// InputError marks err as a problem with the input data.
+func InputError(err error) error {
+	return &Error{Input, err}
+}
+
+// RenderError marks err as a failure to draw or write the chart.
+func RenderError(err error) error {
+	return &Error{Render, err}
+}
+
+// Code is the status to exit with for err: the one it was marked
+// with, or Render for anything unmarked.
+func Code(err error) int {
+	var e *Error
+	if errors.As(err, &e) {
+		return e.Code
+	}
+	return Render
+}
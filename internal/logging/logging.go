@@ -0,0 +1,123 @@
+// Package logging prints warnings, notes and verbose details, as text or
+// as JSON records.
+package logging
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// Rendering reports on stderr, so that stdout only carries output asked
+// for on purpose, such as -palette list. How much it reports depends on
+// the log level.
+type Level int
+
+const (
+	Quiet   Level = iota // errors only
+	Normal               // warnings and a one-line summary per chart
+	Verbose              // also a note on each step and the adjustment log
+)
+
+var (
+	// level is set from -quiet and -verbose once the flags are read.
+	level = Normal
+	// logJSON is set by -log-json: stderr then carries logRecords, one
+	// per line, instead of text.
+	logJSON bool
+	// warningCount counts warnings, printed or not, for the summary.
+	warningCount int
+)
+
+// Record is one line of -log-json output. Event says which other
+// fields are set:
+//
+//	"warning"     Message
+//	"error"       Message; the run stops after it with a nonzero status
+//	"adjustment"  Output and Adjustment, one per point of each chart
+//	"summary"     Summary, once at the end of a successful run
+//
+// Fields are only ever added, so scripts can rely on these.
+type Record struct {
+	Event      string `json:"event"`
+	Message    string `json:"message,omitempty"`
+	Output     string `json:"output,omitempty"`
+	Adjustment any    `json:"adjustment,omitempty"`
+	Summary    any    `json:"summary,omitempty"`
+}
+
+// SetLevel sets level from the -quiet and -verbose switches, and
+// switches to JSON records when asJSON is set.
+func SetLevel(quiet, verbose, asJSON bool) {
+	switch {
+	case quiet:
+		level = Quiet
+	case verbose:
+		level = Verbose
+	default:
+		level = Normal
+	}
+	logJSON = asJSON
+}
+
+// Emit writes r as a line of JSON to stderr.
+func Emit(r Record) {
+	json.NewEncoder(os.Stderr).Encode(r)
+}
+
+// ReportError prints the error that ended the run, as an error record
+// with -log-json.
+func ReportError(err error) {
+	if logJSON {
+		Emit(Record{Event: "error", Message: err.Error()})
+		return
+	}
+	fmt.Fprintf(os.Stderr, "lifeline: %v\n", err)
+}
+
+// Warnf prints a warning line unless -quiet is set.
+func Warnf(format string, a ...any) {
+	warningCount++
+	if level < Normal {
+		return
+	}
+	if logJSON {
+		Emit(Record{Event: "warning", Message: fmt.Sprintf(format, a...)})
+		return
+	}
+	fmt.Fprintf(os.Stderr, "Warning: "+format+"\n", a...)
+}
+
+// Notef prints format as is unless -quiet or -log-json is set.
+func Notef(format string, a ...any) {
+	if level >= Normal && !logJSON {
+		fmt.Fprintf(os.Stderr, format, a...)
+	}
+}
+
+// Logf prints format as is with -verbose only, and not with -log-json,
+// which has adjust.Log instead.
+func Logf(format string, a ...any) {
+	if level >= Verbose && !logJSON {
+		fmt.Fprintf(os.Stderr, format, a...)
+	}
+}
+
+// JSONRecords reports whether -log-json records such as adjustments and
+// the summary are being written.
+func JSONRecords() bool {
+	return logJSON && level >= Normal
+}
+
+// Warnings is the number of warnings so far, printed or not.
+func Warnings() int {
+	return warningCount
+}
+
+// Mute silences everything but errors until the returned function is
+// called, which also forgets the warnings counted in between.
+func Mute() (restore func()) {
+	savedLevel, savedWarnings := level, warningCount
+	level = Quiet
+	return func() { level, warningCount = savedLevel, savedWarnings }
+}
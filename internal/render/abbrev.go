@@ -0,0 +1,75 @@
+package render
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"github.com/rojaswestall/lifeline/internal/timeline"
+)
+
+// crowdWindow is how many years either side of an event count towards
+// its crowding for -auto-abbreviate.
+const crowdWindow = 3
+
+// crowding returns, for each of the chronologically sorted pts, how many
+// events lie within crowdWindow years of it in their original years,
+// itself included. Every event counts once, whatever its importance, and
+// interpolated or zero-filled points don't count.
+func crowding(pts []timeline.Point) []int {
+	counts := make([]int, len(pts))
+	lo, hi, n := 0, 0, 0
+	for i, p := range pts {
+		for hi < len(pts) && pts[hi].Year <= p.Year+crowdWindow {
+			if isEvent(pts[hi]) {
+				n++
+			}
+			hi++
+		}
+		for pts[lo].Year < p.Year-crowdWindow {
+			if isEvent(pts[lo]) {
+				n--
+			}
+			lo++
+		}
+		counts[i] = n
+	}
+	return counts
+}
+
+// isEvent reports whether p comes from the input rather than being
+// filled in.
+func isEvent(p timeline.Point) bool { return !p.Interpolated && !p.ZeroFilled }
+
+// articles are dropped first when abbreviating a label.
+var articles = map[string]bool{"a": true, "an": true, "the": true}
+
+// abbreviate shortens label to at most maxLen characters: it drops
+// articles, keeps only the first clause before a comma, and finally
+// truncates with an ellipsis, at a word boundary where possible.
+func abbreviate(label string, maxLen int) string {
+	var words []string
+	for _, w := range strings.Fields(label) {
+		if !articles[strings.ToLower(w)] {
+			words = append(words, w)
+		}
+	}
+	s := strings.Join(words, " ")
+	if s == "" {
+		s = label
+	}
+	if utf8.RuneCountInString(s) <= maxLen {
+		return s
+	}
+	if clause, _, ok := strings.Cut(s, ","); ok && clause != "" {
+		s = clause
+	}
+	if r := []rune(s); len(r) > maxLen {
+		// Prefer breaking between words when that keeps most of the room.
+		cut := string(r[:maxLen-1])
+		if i := strings.LastIndex(cut, " "); i >= len(cut)/2 {
+			cut = cut[:i]
+		}
+		s = strings.TrimSpace(cut) + "…"
+	}
+	return s
+}
@@ -0,0 +1,22 @@
+package render
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/rojaswestall/lifeline/internal/timeline"
+)
+
+func TestCrowding(t *testing.T) {
+	years := []float64{2000, 2001, 2003, 2003, 2007, 2011, 2020}
+	pts := make([]timeline.Point, len(years))
+	for i, y := range years {
+		// Importance doesn't make an event count for more.
+		pts[i] = timeline.Point{Year: y, Importance: float64(i + 1)}
+	}
+	pts[5].Interpolated = true
+	want := []int{4, 4, 4, 4, 1, 0, 1}
+	if got := crowding(pts); !slices.Equal(got, want) {
+		t.Errorf("crowding = %v, want %v", got, want)
+	}
+}
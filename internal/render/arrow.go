@@ -0,0 +1,29 @@
+package render
+
+import (
+	"image/color"
+
+	"gonum.org/v1/plot"
+	"gonum.org/v1/plot/vg"
+	"gonum.org/v1/plot/vg/draw"
+)
+
+// arrowHead draws a filled right-pointing triangle whose tip sits at
+// (X, Y) in data coordinates. It is used to cap the zero axis line.
+type arrowHead struct {
+	X, Y   float64
+	Color  color.Color
+	Length vg.Length // distance from base to tip
+	Width  vg.Length // full width of the base
+}
+
+// Plot implements the plot.Plotter interface.
+func (a arrowHead) Plot(c draw.Canvas, plt *plot.Plot) {
+	trX, trY := plt.Transforms(&c)
+	tip := vg.Point{X: trX(a.X), Y: trY(a.Y)}
+	c.FillPolygon(a.Color, []vg.Point{
+		tip,
+		{X: tip.X - a.Length, Y: tip.Y + a.Width/2},
+		{X: tip.X - a.Length, Y: tip.Y - a.Width/2},
+	})
+}
@@ -0,0 +1,98 @@
+package render
+
+import (
+	"image/color"
+	"sort"
+
+	"gonum.org/v1/plot"
+	"gonum.org/v1/plot/plotter"
+	"gonum.org/v1/plot/vg"
+	"gonum.org/v1/plot/vg/draw"
+)
+
+// interpAt returns the straight-line value of xys, sorted by X, at x.
+// Outside the data it holds the nearest end value.
+func interpAt(xys plotter.XYs, x float64) float64 {
+	i := sort.Search(len(xys), func(i int) bool { return xys[i].X >= x })
+	switch {
+	case i == 0:
+		return xys[0].Y
+	case i == len(xys):
+		return xys[len(xys)-1].Y
+	}
+	a, b := xys[i-1], xys[i]
+	if b.X == a.X {
+		return b.Y
+	}
+	return a.Y + (b.Y-a.Y)*(x-a.X)/(b.X-a.X)
+}
+
+// betweenShade fills the area between Line and Base where their x ranges
+// overlap, in Positive where Line is above Base and Negative below.
+type betweenShade struct {
+	Line, Base         plotter.XYs
+	Positive, Negative color.Color
+}
+
+// Plot implements the plot.Plotter interface.
+func (s *betweenShade) Plot(c draw.Canvas, plt *plot.Plot) {
+	if len(s.Line) < 2 || len(s.Base) < 2 {
+		return
+	}
+	lo := max(s.Line[0].X, s.Base[0].X)
+	hi := min(s.Line[len(s.Line)-1].X, s.Base[len(s.Base)-1].X)
+	var xs []float64
+	for _, xys := range []plotter.XYs{s.Line, s.Base} {
+		for _, xy := range xys {
+			if xy.X >= lo && xy.X <= hi {
+				xs = append(xs, xy.X)
+			}
+		}
+	}
+	sort.Float64s(xs)
+
+	// The difference curve splits into same-sign runs the way signShade
+	// splits the line at zero.
+	diff := make(plotter.XYs, len(xs))
+	for i, x := range xs {
+		diff[i] = plotter.XY{X: x, Y: interpAt(s.Line, x) - interpAt(s.Base, x)}
+	}
+	trX, trY := plt.Transforms(&c)
+	runs, above := signRuns(diff)
+	for i, run := range runs {
+		poly := make([]vg.Point, 0, 2*len(run))
+		for _, xy := range run {
+			poly = append(poly, vg.Point{X: trX(xy.X), Y: trY(interpAt(s.Line, xy.X))})
+		}
+		for j := len(run) - 1; j >= 0; j-- {
+			poly = append(poly, vg.Point{X: trX(run[j].X), Y: trY(interpAt(s.Base, run[j].X))})
+		}
+		clr := s.Negative
+		if above[i] {
+			clr = s.Positive
+		}
+		c.FillPolygon(clr, c.ClipPolygonXY(poly))
+	}
+}
+
+// clipXYs trims xys, sorted by X, to [lo, hi], adding interpolated end
+// points where the data runs past either side. Adding the clipped line to
+// a plot then leaves its x-range alone.
+func clipXYs(xys plotter.XYs, lo, hi float64) plotter.XYs {
+	if len(xys) == 0 {
+		return nil
+	}
+	var out plotter.XYs
+	if xys[0].X < lo && xys[len(xys)-1].X > lo {
+		out = append(out, plotter.XY{X: lo, Y: interpAt(xys, lo)})
+	}
+	for _, xy := range xys {
+		if xy.X >= lo && xy.X <= hi {
+			out = append(out, xy)
+		}
+	}
+	if xys[len(xys)-1].X > hi && xys[0].X < hi {
+		out = append(out, plotter.XY{X: hi, Y: interpAt(xys, hi)})
+	}
+	return out
+}
@@ -0,0 +1,13 @@
+package render
+
+import (
+	"math"
+
+	"gonum.org/v1/plot/vg"
+)
+
+// bucketRadius sizes a bin's marker by its event count, growing with the
+// square root so the area tracks the count.
+func bucketRadius(base vg.Length, count int) vg.Length {
+	return min(base*vg.Length(math.Sqrt(float64(count))), 4*base)
+}
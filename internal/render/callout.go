@@ -0,0 +1,151 @@
+package render
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gonum.org/v1/plot"
+	"gonum.org/v1/plot/font"
+	"gonum.org/v1/plot/vg"
+	"gonum.org/v1/plot/vg/draw"
+
+	"github.com/rojaswestall/lifeline/internal/timeline"
+)
+
+// callout is a boxed note pointing at one point.
+type callout struct {
+	X, Y float64 // plotted position of the point
+	Text string
+}
+
+// parseCallout parses a -callout value of the form "YEAR:text".
+func parseCallout(s string) (year float64, txt string, err error) {
+	y, txt, ok := strings.Cut(s, ":")
+	if ok {
+		year, err = strconv.ParseFloat(strings.TrimSpace(y), 64)
+	}
+	if !ok || err != nil || strings.TrimSpace(txt) == "" {
+		return 0, "", fmt.Errorf("%q: use YEAR:text, e.g. 2019:Everything changed here", s)
+	}
+	return year, strings.TrimSpace(txt), nil
+}
+
+// nearestPoint returns the index of the point whose year is closest to
+// year, preferring the earlier one on ties.
+func nearestPoint(pts []timeline.Point, year float64) int {
+	best := 0
+	for i, p := range pts {
+		if math.Abs(p.Year-year) < math.Abs(pts[best].Year-year) {
+			best = i
+		}
+	}
+	return best
+}
+
+// callouts draws rounded boxes a quarter of the plot height (at least
+// Distance) away from their points, joined to them by arrowed leaders. Boxes go on the emptier side of each point, above
+// points in the lower half of the plot and below those in the upper half,
+// and move further out until they clear earlier boxes.
+type callouts struct {
+	Items []callout
+
+	TextStyle draw.TextStyle
+	LineStyle draw.LineStyle
+	Distance  vg.Length // least distance from point to the near edge of its box
+}
+
+func newCallouts(items []callout) *callouts {
+	return &callouts{
+		Items: items,
+		TextStyle: draw.TextStyle{
+			Color:   color.Black,
+			Font:    font.From(plot.DefaultFont, vg.Points(9)),
+			XAlign:  draw.XCenter,
+			YAlign:  draw.YCenter,
+			Handler: plot.DefaultTextHandler,
+		},
+		LineStyle: draw.LineStyle{Color: color.Gray{Y: 90}, Width: vg.Points(0.75)},
+		Distance:  vg.Points(48),
+	}
+}
+
+// Plot implements the plot.Plotter interface.
+func (co *callouts) Plot(c draw.Canvas, plt *plot.Plot) {
+	trX, trY := plt.Transforms(&c)
+	items := append([]callout(nil), co.Items...)
+	sort.SliceStable(items, func(i, j int) bool { return items[i].X < items[j].X })
+
+	const pad = 5 // points of space around the text inside a box
+	var placed []vg.Rectangle
+	for _, it := range items {
+		pt := vg.Point{X: trX(it.X), Y: trY(it.Y)}
+		w := co.TextStyle.Width(it.Text) + 2*vg.Points(pad)
+		h := co.TextStyle.Height(it.Text) + 2*vg.Points(pad)
+		dir := vg.Length(1)
+		if pt.Y > (c.Min.Y+c.Max.Y)/2 {
+			dir = -1
+		}
+
+		cx := clampBox(pt.X, w, c.Min.X, c.Max.X)
+		dist := max(co.Distance, (c.Max.Y-c.Min.Y)/4)
+		cy := pt.Y + dir*(dist+h/2)
+		box := func() vg.Rectangle {
+			return vg.Rectangle{Min: vg.Point{X: cx - w/2, Y: cy - h/2}, Max: vg.Point{X: cx + w/2, Y: cy + h/2}}
+		}
+		for overlapsAny(box(), placed) {
+			cy += dir * (h + vg.Points(4))
+		}
+		r := box()
+		placed = append(placed, r)
+
+		// Leader from the box edge to just short of the point.
+		from := vg.Point{X: cx, Y: cy - dir*h/2}
+		d := pt.Sub(from)
+		l := vg.Length(math.Hypot(float64(d.X), float64(d.Y)))
+		if l > vg.Points(6) {
+			u := d.Scale(1 / l)
+			tip := pt.Sub(u.Scale(vg.Points(4)))
+			c.StrokeLine2(co.LineStyle, from.X, from.Y, tip.X, tip.Y)
+			back := tip.Sub(u.Scale(vg.Points(6)))
+			side := vg.Point{X: -u.Y, Y: u.X}.Scale(vg.Points(2.5))
+			c.FillPolygon(co.LineStyle.Color, []vg.Point{tip, back.Add(side), back.Sub(side)})
+		}
+
+		outline := roundedRect(r, vg.Points(4))
+		c.SetColor(color.White)
+		c.Fill(outline)
+		c.SetLineStyle(co.LineStyle)
+		c.Stroke(outline)
+		c.FillText(co.TextStyle, vg.Point{X: cx, Y: cy}, it.Text)
+	}
+}
+
+// overlapsAny reports whether r intersects any of rs.
+func overlapsAny(r vg.Rectangle, rs []vg.Rectangle) bool {
+	for _, o := range rs {
+		if rectsOverlap(r, o) {
+			return true
+		}
+	}
+	return false
+}
+
+// roundedRect returns the outline of r with corners of the given radius.
+func roundedRect(r vg.Rectangle, rad vg.Length) vg.Path {
+	var p vg.Path
+	p.Move(vg.Point{X: r.Min.X + rad, Y: r.Min.Y})
+	p.Line(vg.Point{X: r.Max.X - rad, Y: r.Min.Y})
+	p.Arc(vg.Point{X: r.Max.X - rad, Y: r.Min.Y + rad}, rad, -math.Pi/2, math.Pi/2)
+	p.Line(vg.Point{X: r.Max.X, Y: r.Max.Y - rad})
+	p.Arc(vg.Point{X: r.Max.X - rad, Y: r.Max.Y - rad}, rad, 0, math.Pi/2)
+	p.Line(vg.Point{X: r.Min.X + rad, Y: r.Max.Y})
+	p.Arc(vg.Point{X: r.Min.X + rad, Y: r.Max.Y - rad}, rad, math.Pi/2, math.Pi/2)
+	p.Line(vg.Point{X: r.Min.X, Y: r.Min.Y + rad})
+	p.Arc(vg.Point{X: r.Min.X + rad, Y: r.Min.Y + rad}, rad, math.Pi, math.Pi/2)
+	p.Close()
+	return p
+}
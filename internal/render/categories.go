@@ -0,0 +1,65 @@
+package render
+
+import (
+	"image/color"
+	"math"
+
+	"gonum.org/v1/plot/vg"
+	"gonum.org/v1/plot/vg/draw"
+)
+
+// categoryShapes are cycled through by category, in sorted name order, so
+// categories stay distinguishable without color.
+var categoryShapes = []draw.GlyphDrawer{
+	draw.CircleGlyph{},
+	draw.BoxGlyph{},
+	draw.PyramidGlyph{},
+	diamondGlyph{},
+}
+
+// categoryStyle is the marker color and shape of one category.
+type categoryStyle struct {
+	Color color.Color
+	Shape draw.GlyphDrawer
+}
+
+// categoryStyles assigns each category a palette color and, when shapes
+// is set, a shape. Assignment follows the sorted names, so it is stable
+// across renders.
+func categoryStyles(names []string, pal palette, shapes bool) map[string]categoryStyle {
+	styles := make(map[string]categoryStyle, len(names))
+	for i, name := range names {
+		var shape draw.GlyphDrawer = draw.CircleGlyph{}
+		if shapes {
+			shape = categoryShapes[i%len(categoryShapes)]
+		}
+		styles[name] = categoryStyle{Color: pal.seriesColor(i), Shape: shape}
+	}
+	return styles
+}
+
+// diamondGlyph is a filled square standing on one corner.
+type diamondGlyph struct{}
+
+// DrawGlyph implements the draw.GlyphDrawer interface.
+func (diamondGlyph) DrawGlyph(c *draw.Canvas, sty draw.GlyphStyle, pt vg.Point) {
+	c.SetColor(sty.Color)
+	r := sty.Radius * vg.Length(math.Sqrt2) * 0.9
+	var p vg.Path
+	p.Move(vg.Point{X: pt.X, Y: pt.Y + r})
+	p.Line(vg.Point{X: pt.X + r, Y: pt.Y})
+	p.Line(vg.Point{X: pt.X, Y: pt.Y - r})
+	p.Line(vg.Point{X: pt.X - r, Y: pt.Y})
+	p.Close()
+	c.Fill(p)
+}
+
+// legendGlyph is a legend thumbnail showing a single marker.
+type legendGlyph struct {
+	Style draw.GlyphStyle
+}
+
+// Thumbnail implements the plot.Thumbnailer interface.
+func (l legendGlyph) Thumbnail(c *draw.Canvas) {
+	c.DrawGlyph(l.Style, c.Center())
+}
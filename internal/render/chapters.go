@@ -0,0 +1,105 @@
+package render
+
+import (
+	"encoding/csv"
+	"fmt"
+	"image/color"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gonum.org/v1/plot"
+	"gonum.org/v1/plot/font"
+	"gonum.org/v1/plot/vg"
+	"gonum.org/v1/plot/vg/draw"
+
+	"github.com/rojaswestall/lifeline/internal/adjust"
+)
+
+// Chapter is one named period of life starting at Start.
+type Chapter struct {
+	Start float64
+	Name  string
+}
+
+// readChapters loads chapters from a CSV file whose rows are:
+// startYear,name
+func readChapters(path string) ([]Chapter, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	r := csv.NewReader(f)
+	r.FieldsPerRecord = -1
+	rows, err := r.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+
+	var chs []Chapter
+	for i, row := range rows {
+		if len(row) < 2 {
+			return nil, fmt.Errorf("%s row %d: expected startYear,name", path, i+1)
+		}
+		start, err := strconv.ParseFloat(strings.TrimSpace(row[0]), 64)
+		if err != nil {
+			return nil, fmt.Errorf("%s row %d: invalid start year %q: %w", path, i+1, row[0], err)
+		}
+		chs = append(chs, Chapter{Start: start, Name: strings.TrimSpace(row[1])})
+	}
+	sort.SliceStable(chs, func(i, j int) bool { return chs[i].Start < chs[j].Start })
+	return chs, nil
+}
+
+// chapterBands draws a faint full-height divider at the start of each
+// chapter and the chapter name, large and light, centered in its band.
+// It is meant to be added before everything else so it stays behind.
+type chapterBands struct {
+	Names []string
+	Xs    []float64 // plotted x of each chapter start
+
+	LineStyle draw.LineStyle
+	TextStyle draw.TextStyle
+}
+
+func newChapterBands(chs []Chapter, m adjust.YearMap) *chapterBands {
+	b := &chapterBands{
+		LineStyle: draw.LineStyle{Color: color.Gray{Y: 215}, Width: vg.Points(0.75)},
+		TextStyle: draw.TextStyle{
+			Color:   color.Gray{Y: 225},
+			Font:    font.From(plot.DefaultFont, vg.Points(22)),
+			XAlign:  draw.XCenter,
+			YAlign:  draw.YCenter,
+			Handler: plot.DefaultTextHandler,
+		},
+	}
+	for _, ch := range chs {
+		b.Names = append(b.Names, ch.Name)
+		b.Xs = append(b.Xs, m.X(ch.Start))
+	}
+	return b
+}
+
+// Plot implements the plot.Plotter interface.
+func (b *chapterBands) Plot(c draw.Canvas, plt *plot.Plot) {
+	trX, _ := plt.Transforms(&c)
+	for i, x := range b.Xs {
+		end := plt.X.Max
+		if i+1 < len(b.Xs) {
+			end = b.Xs[i+1]
+		}
+		if end <= plt.X.Min || x >= plt.X.Max {
+			continue
+		}
+		start := max(x, plt.X.Min)
+		end = min(end, plt.X.Max)
+		if x > plt.X.Min {
+			c.StrokeLine2(b.LineStyle, trX(x), c.Min.Y, trX(x), c.Max.Y)
+		}
+		mid := vg.Point{X: (trX(start) + trX(end)) / 2, Y: c.Max.Y - (c.Max.Y-c.Min.Y)/12}
+		c.FillText(b.TextStyle, mid, b.Names[i])
+	}
+}
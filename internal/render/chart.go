@@ -0,0 +1,1471 @@
+// Package render draws a timeline chart from its points and saves it.
+package render
+
+import (
+	"errors"
+	"fmt"
+	"image/color"
+	"math"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/template"
+
+	xfont "golang.org/x/image/font"
+	"gonum.org/v1/plot"
+	"gonum.org/v1/plot/plotter"
+	"gonum.org/v1/plot/vg"
+	"gonum.org/v1/plot/vg/draw"
+
+	"github.com/rojaswestall/lifeline/internal/adjust"
+	"github.com/rojaswestall/lifeline/internal/exit"
+	"github.com/rojaswestall/lifeline/internal/logging"
+	"github.com/rojaswestall/lifeline/internal/timeline"
+)
+
+// Options are the settings that shape a chart, each set by the
+// render flag named in its comment. Check parses and validates them
+// before the first chart is drawn.
+type Options struct {
+	ShowYears          bool     // -years
+	Decades            bool     // -decades
+	DecadeLabels       bool     // -decade-labels
+	TickEvery          float64  // -tick-every
+	MinorTicks         bool     // -minor-ticks
+	Grid               string   // -grid
+	GridColor          string   // -grid-color
+	GridStyle          string   // -grid-style
+	Arrow              bool     // -arrow
+	ArrowExtend        float64  // -arrow-extend
+	LabelSize          float64  // -label-size
+	TitleSize          float64  // -title-size
+	TitleColor         string   // -title-color
+	TitleAlign         string   // -title-align
+	NoTitle            bool     // -no-title
+	PadX               string   // -pad-x
+	PadY               string   // -pad-y
+	Margin             float64  // -margin
+	Clamp              string   // -clamp
+	YRange             string   // -y-range
+	YTicks             bool     // -y-ticks
+	YLabel             string   // -y-label
+	ShowValues         bool     // -show-values
+	ValueFormat        string   // -value-format
+	UncertaintyCol     string   // -uncertainty-col
+	SpanLane           bool     // -span-lane
+	Chapters           string   // -chapters
+	NoAdjust           bool     // -no-adjust
+	River              bool     // -river
+	Stems              bool     // -stems
+	LabelLanes         int      // -label-lanes
+	LineDash           string   // -line-dash
+	LineWidth          float64  // -line-width
+	ShadeSign          bool     // -shade-sign
+	ShadePositive      string   // -shade-positive
+	ShadeNegative      string   // -shade-negative
+	ShadeOpacity       float64  // -shade-opacity
+	PhotoCol           string   // -photo-col
+	PhotoSize          float64  // -photo-size
+	ValueLabel         string   // -value-label
+	ValueArrows        bool     // -value-arrows
+	Width              float64  // -width
+	Height             float64  // -height
+	AutoSize           bool     // -auto-size
+	MaxWidth           float64  // -max-width
+	LabelAngle         float64  // -label-angle
+	Adjust             string   // -adjust
+	MaxCompression     float64  // -max-compression
+	DensityWindow      float64  // -density-window
+	MinSpacing         string   // -min-spacing
+	Palette            string   // -palette
+	Grayscale          bool     // -grayscale
+	Now                string   // -now
+	GapYears           float64  // -gap-years
+	GapStyle           string   // -gap-style
+	CategoryCol        string   // -category-col
+	CategoryShapes     string   // -category-shapes
+	AnnotateGaps       bool     // -annotate-gaps
+	QuietYears         float64  // -quiet-years
+	MeanLine           bool     // -mean-line
+	Cumulative         bool     // -cumulative
+	Slopes             bool     // -slopes
+	SlopeThreshold     float64  // -slope-threshold
+	Band               string   // -band
+	BandMin            int      // -band-min
+	AutoAbbreviate     bool     // -auto-abbreviate
+	AbbreviateDensity  float64  // -abbreviate-density
+	AbbreviateLength   int      // -abbreviate-length
+	DefaultLabelFormat string   // -default-label-format
+	LineColor          string   // -line-color
+	MarkerColor        string   // -marker-color
+	AxisColor          string   // -axis-color
+	TickSize           float64  // -tick-size
+	Callouts           []string // -callout
+	Project            float64  // -project
+	Aggregate          string   // -aggregate
+	SmoothData         string   // -smooth-data
+	Normalize          []string // -normalize
+	NoOutlierCheck     bool     // -no-outlier-check
+	OutlierK           float64  // -outlier-k
+	DropOutliers       bool     // -drop-outliers
+	PolarityStyle      string   // -polarity-style
+	SameYearOrder      string   // -same-year-order
+	Interpolate        bool     // -interpolate
+	FillZero           bool     // -fill-zero
+	NoDupCheck         bool     // -no-dup-check
+	InvertValues       bool     // -invert-values
+	Invert             []string // -invert
+	Baseline           string   // -baseline
+	BaselineShade      bool     // -baseline-shade
+	Bucket             int      // -bucket
+	BucketLabel        string   // -bucket-label
+	BucketRaw          bool     // -bucket-raw
+	ImportanceCol      string   // -importance-col
+	DensityWeighted    bool     // -density-weighted
+	OrderRepair        string   // -order-repair
+	AllowFuture        bool     // -allow-future
+	FutureMargin       float64  // -future-margin
+	BirthYear          float64  // -birthyear
+	FractionalMonths   bool     // -fractional-as-month
+	AutoScale          bool     // -auto-scale
+	Streaks            float64  // -streaks
+	StreakLabels       bool     // -streak-labels
+	LabelRule          string   // -label-rule
+
+	// Categories are the categories of every event in the run, so a
+	// category keeps its color and shape in each chart it appears in.
+	Categories []string
+
+	// Parsed by Check.
+	pal          palette
+	axisColor    color.RGBA
+	titleColor   color.RGBA
+	gridColor    color.Color
+	lineDashes   []vg.Length
+	nowYear      float64
+	labelRule    labelRule
+	defaultLabel *template.Template
+	normalize    []timeline.Normalization
+}
+
+// Check validates the options and parses those that need it, reporting
+// the first flag in error.
+func (o *Options) Check() error {
+	var err error
+	o.pal, err = lookupPalette(o.Palette)
+	if err != nil {
+		return fmt.Errorf("invalid -palette: %v", err)
+	}
+	if o.Grayscale {
+		o.pal = grayscalePalette(o.pal)
+		o.LineWidth *= 1.5
+	}
+	if o.LineColor != "" {
+		if o.pal.Line, err = parseColor(o.LineColor); err != nil {
+			return fmt.Errorf("invalid -line-color: %v", err)
+		}
+	}
+	if o.MarkerColor != "" {
+		if o.pal.Marker, err = parseColor(o.MarkerColor); err != nil {
+			return fmt.Errorf("invalid -marker-color: %v", err)
+		}
+	}
+	o.axisColor, err = parseColor(o.AxisColor)
+	if err != nil {
+		return fmt.Errorf("invalid -axis-color: %v", err)
+	}
+	if o.CategoryShapes != "on" && o.CategoryShapes != "off" {
+		return fmt.Errorf("invalid -category-shapes %q: use on or off", o.CategoryShapes)
+	}
+	if o.QuietYears < 0 {
+		return fmt.Errorf("invalid -quiet-years %v: must not be negative", o.QuietYears)
+	}
+	if o.DefaultLabelFormat != "" {
+		if o.defaultLabel, err = parseDefaultLabel(o.DefaultLabelFormat); err != nil {
+			return fmt.Errorf("invalid -default-label-format: %v", err)
+		}
+	}
+	if o.AbbreviateLength < 2 {
+		return fmt.Errorf("invalid -abbreviate-length %d: must be at least 2", o.AbbreviateLength)
+	}
+	if o.BandMin < 1 {
+		return fmt.Errorf("invalid -band-min %d: must be at least 1", o.BandMin)
+	}
+	if o.GapYears < 0 {
+		return fmt.Errorf("invalid -gap-years %v: must not be negative", o.GapYears)
+	}
+	if o.GapStyle != "dotted" && o.GapStyle != "break" {
+		return fmt.Errorf("invalid -gap-style %q: use dotted or break", o.GapStyle)
+	}
+	o.nowYear, err = timeline.ParseNow(o.Now)
+	if err != nil {
+		return fmt.Errorf("invalid -now: %v", err)
+	}
+	o.labelRule, err = parseLabelRule(o.LabelRule)
+	if err != nil {
+		return fmt.Errorf("invalid -label-rule: %v", err)
+	}
+	if o.TickEvery < 0 {
+		return fmt.Errorf("invalid -tick-every %v: must be positive", o.TickEvery)
+	}
+	if o.Adjust != "spacing" && o.Adjust != "legacy" {
+		return fmt.Errorf("invalid -adjust %q: use spacing or legacy", o.Adjust)
+	}
+	if _, err := adjust.ParseSpacing(o.MinSpacing); err != nil {
+		return fmt.Errorf("invalid -min-spacing: %v", err)
+	}
+	switch o.OrderRepair {
+	case "squeeze", "push", "fail":
+	default:
+		return fmt.Errorf("invalid -order-repair %q: use squeeze, push or fail", o.OrderRepair)
+	}
+	if o.FutureMargin < 0 {
+		return fmt.Errorf("invalid -future-margin %v: must not be negative", o.FutureMargin)
+	}
+	if o.Streaks < 0 {
+		return fmt.Errorf("invalid -streaks %v: must not be negative", o.Streaks)
+	}
+	if o.DensityWindow < 0 {
+		return fmt.Errorf("invalid -density-window %v: must not be negative", o.DensityWindow)
+	}
+	if o.MaxCompression < 0 || o.MaxCompression > 1 {
+		return fmt.Errorf("invalid -max-compression %v: must be between 0 and 1", o.MaxCompression)
+	}
+	if o.TickSize <= 0 {
+		return fmt.Errorf("invalid -tick-size %v: must be positive", o.TickSize)
+	}
+	if o.TitleSize <= 0 {
+		return fmt.Errorf("invalid -title-size %v: must be positive", o.TitleSize)
+	}
+	o.titleColor, err = parseColor(o.TitleColor)
+	if err != nil {
+		return fmt.Errorf("invalid -title-color: %v", err)
+	}
+	o.lineDashes, err = parseDashes(o.LineDash)
+	if err != nil {
+		return fmt.Errorf("invalid -line-dash: %v", err)
+	}
+	if o.LineWidth <= 0 {
+		return fmt.Errorf("invalid -line-width %v: must be positive", o.LineWidth)
+	}
+	if o.Width < 0 || o.Height < 0 || o.MaxWidth <= 0 {
+		return errors.New("invalid canvas size: -width, -height and -max-width must be positive")
+	}
+	if o.PhotoSize <= 0 {
+		return fmt.Errorf("invalid -photo-size %v: must be positive", o.PhotoSize)
+	}
+	if o.LabelLanes < 0 {
+		return fmt.Errorf("invalid -label-lanes %d: must not be negative", o.LabelLanes)
+	}
+	if o.LabelSize <= 0 {
+		return fmt.Errorf("invalid -label-size %v: must be positive", o.LabelSize)
+	}
+	if strings.Contains(fmt.Sprintf(o.ValueFormat, 1.0), "%!") {
+		return fmt.Errorf("invalid -value-format %q: use a float verb such as %%.1f", o.ValueFormat)
+	}
+	if o.Margin < 0 {
+		return fmt.Errorf("invalid -margin %v: must not be negative", o.Margin)
+	}
+	switch o.Grid {
+	case "off", "horizontal", "vertical", "both":
+	default:
+		return fmt.Errorf("invalid -grid %q: use off, horizontal, vertical, or both", o.Grid)
+	}
+	if o.GridStyle != "solid" && o.GridStyle != "dotted" {
+		return fmt.Errorf("invalid -grid-style %q: use solid or dotted", o.GridStyle)
+	}
+	if o.PolarityStyle != "off" && o.PolarityStyle != "shape" {
+		return fmt.Errorf("invalid -polarity-style %q: use off or shape", o.PolarityStyle)
+	}
+	if o.GridColor != "" {
+		c, err := parseColor(o.GridColor)
+		if err != nil {
+			return fmt.Errorf("invalid -grid-color: %v", err)
+		}
+		o.gridColor = c
+	}
+	o.normalize = nil
+	for _, s := range o.Normalize {
+		n, err := timeline.ParseNormalization(s)
+		if err != nil {
+			return fmt.Errorf("invalid -normalize: %v", err)
+		}
+		for _, m := range o.normalize {
+			if m.File == n.File {
+				return fmt.Errorf("invalid -normalize %q: %s already has a mapping", s, describeFile(n.File))
+			}
+		}
+		o.normalize = append(o.normalize, n)
+	}
+	return nil
+}
+
+// Spec is what differs between the charts of one run.
+type Spec struct {
+	Title  string
+	Footer string // the footer text, if any
+	Input  string // the CSV the points were read from
+	Output string // the image the chart is for
+	// Shared, when set, is used for the axis ranges instead of fitting
+	// them to the points.
+	Shared *Frame
+}
+
+// Chart is a drawn chart, ready to be saved.
+type Chart struct {
+	Plot   *plot.Plot
+	W, H   vg.Length // the canvas size the chart was laid out for
+	Footer *Footer
+	// Frame is the chart's axis ranges, before any span strip or label
+	// lanes are added.
+	Frame       Frame
+	Points      []timeline.Point // the points as plotted
+	Adjustments []adjust.Adjustment
+	Overlaps    []string // overlapping labels, as reportOverlaps lists them
+}
+
+// Draw draws points, which it may change in place, as set out by o
+// and spec.
+func Draw(points []timeline.Point, o *Options, spec Spec) (*Chart, error) {
+	yearStr := func(y float64) string { return timeline.FormatYear(y, o.FractionalMonths) }
+
+	if err := timeline.OrderSameYear(points, o.SameYearOrder); err != nil {
+		return nil, exit.UsageErrorf("invalid -same-year-order: %v", err)
+	}
+
+	normalized, err := o.normalizeValues(spec.Input, points)
+	if err != nil {
+		return nil, err
+	}
+	autoScaled := false
+	if !normalized && timeline.LooksLikePercent(points) {
+		if o.AutoScale {
+			timeline.PercentScale.Apply(points)
+			autoScaled = true
+			logging.Logf("Auto-scaled values %s (50 is now the zero line)\n", timeline.PercentScale)
+		} else {
+			logging.Warnf("every value is between 0 and 100, which the -10..10 frame doesn't suit; add -auto-scale to map 0:100 onto -10:10 (50 becomes 0)")
+		}
+	}
+	inverted := o.invertValues(spec.Input, points)
+	if normalized || autoScaled || inverted || o.FractionalMonths {
+		// Generated captions show the number as plotted.
+		for i := range points {
+			if !points[i].Tagged {
+				points[i].Label = timeline.AutoLabel(points[i].Year, points[i].Value, o.FractionalMonths)
+			}
+		}
+	}
+
+	if o.OutlierK <= 0 {
+		return nil, exit.UsageErrorf("invalid -outlier-k %g: must be positive", o.OutlierK)
+	}
+	if !o.NoOutlierCheck {
+		outliers := timeline.FindOutliers(points, o.OutlierK)
+		for _, i := range outliers {
+			logging.Warnf("row %d (%q): value %g is an outlier", points[i].Row, points[i].Label, points[i].Value)
+		}
+		if o.DropOutliers && len(outliers) > 0 {
+			points = timeline.DropIndexes(points, outliers)
+			logging.Logf("Dropped %d outlier(s)\n", len(outliers))
+			if len(points) == 0 {
+				return nil, exit.InputError(errors.New("no data points"))
+			}
+		}
+	} else if o.DropOutliers {
+		return nil, exit.UsageErrorf("-drop-outliers needs the outlier check; remove -no-outlier-check")
+	}
+
+	// A year far past -now is usually a typo (2041 for 2014); the
+	// same -now decides which events are drawn as planned.
+	hi := math.Inf(1)
+	if !o.AllowFuture {
+		hi = o.nowYear + o.FutureMargin
+	}
+	lo := math.Inf(-1)
+	if o.BirthYear != 0 {
+		lo = o.BirthYear
+	}
+	for _, i := range timeline.DatedOutside(points, lo, hi) {
+		pt := points[i]
+		if pt.Year < lo {
+			logging.Warnf("row %d (%q): year %s is before -birthyear %g", pt.Row, pt.Label, yearStr(pt.Year), o.BirthYear)
+			continue
+		}
+		logging.Warnf("row %d (%q): year %s is more than %g year(s) after -now (%.1f); use -allow-future for planned events",
+			pt.Row, pt.Label, yearStr(math.Max(pt.Year, pt.End)), o.FutureMargin, o.nowYear)
+	}
+
+	if !o.NoDupCheck {
+		for _, d := range timeline.FindDuplicates(points) {
+			a, b := points[d[0]], points[d[1]]
+			logging.Warnf("possible duplicates in %s: row %d %q and row %d %q", yearStr(a.Year), a.Row, a.Label, b.Row, b.Label)
+		}
+	}
+
+	// Importance is read before rows are merged, so -aggregate can label
+	// each group by its most important row.
+	hasImportance, err := timeline.ReadImportance(points, strings.ToLower(o.ImportanceCol))
+	if err != nil {
+		return nil, exit.InputError(err)
+	}
+	if o.DensityWeighted && !hasImportance {
+		logging.Warnf("-density-weighted has no effect: no row has a %q column value", o.ImportanceCol)
+	}
+
+	if o.Aggregate != "" {
+		agg, err := timeline.ParseAggregation(o.Aggregate)
+		if err != nil {
+			return nil, exit.UsageErrorf("invalid -aggregate: %v", err)
+		}
+		n := len(points)
+		points = agg.Apply(points)
+		logging.Logf("Aggregated %d rows into %d points by %s\n", n, len(points), agg.Unit)
+	}
+
+	// Bucketing keeps the individual events for the optional faint scatter.
+	var rawEvents []timeline.Point
+	if o.Bucket < 0 {
+		return nil, exit.UsageErrorf("invalid -bucket %d: must not be negative", o.Bucket)
+	}
+	if o.BucketLabel != "range" && o.BucketLabel != "top" {
+		return nil, exit.UsageErrorf("invalid -bucket-label %q: use range or top", o.BucketLabel)
+	}
+	if o.Bucket > 0 {
+		rawEvents = points
+		points = timeline.BucketYears(points, o.Bucket, o.BucketLabel)
+		logging.Logf("Bucketed %d events into %d %d-year bins\n", len(rawEvents), len(points), o.Bucket)
+	}
+
+	if o.Interpolate && o.FillZero {
+		return nil, exit.UsageErrorf("-interpolate and -fill-zero are mutually exclusive: choose how missing years are filled")
+	}
+	if o.Interpolate {
+		var added int
+		points, added = timeline.InterpolateYears(points)
+		logging.Logf("Interpolated %d missing year(s)\n", added)
+	}
+	if o.FillZero {
+		var added int
+		points, added = timeline.FillZeroYears(points)
+		logging.Logf("Filled %d missing year(s) with zero\n", added)
+	}
+
+	// The mean comes from the values as written, before any smoothing or
+	// clamping.
+	mean := timeline.Mean(timeline.Observed(points))
+
+	// Smoothing keeps the raw values so they can be drawn behind the line.
+	var rawValues []float64
+	if o.SmoothData != "" {
+		window, err := timeline.ParseSmoothing(o.SmoothData)
+		if err != nil {
+			return nil, exit.UsageErrorf("invalid -smooth-data: %v", err)
+		}
+		rawValues = make([]float64, len(points))
+		for i, pt := range points {
+			rawValues[i] = pt.Value
+		}
+		for i, v := range timeline.MovingMedian(rawValues, window) {
+			points[i].Value = v
+		}
+	}
+
+	if o.defaultLabel != nil {
+		for i := range points {
+			if !points[i].Tagged {
+				points[i].Label = defaultLabel(o.defaultLabel, points[i])
+			}
+		}
+	}
+
+	if o.UncertaintyCol != "" {
+		if err := timeline.ReadUncertainty(points, strings.ToLower(o.UncertaintyCol)); err != nil {
+			return nil, exit.InputError(err)
+		}
+	}
+
+	// Grayscale output can't tell categories apart by hue, so it always
+	// uses shapes.
+	// Styles come from every category in the file, so a category keeps
+	// its color and shape in its own -split-by-category chart.
+	categories := timeline.ReadCategories(points, strings.ToLower(o.CategoryCol))
+	catStyles := categoryStyles(o.Categories, o.pal, o.CategoryShapes == "on" || o.Grayscale)
+
+	if o.PhotoCol != "" {
+		var missing []string
+		for i := range points {
+			path := points[i].Fields[strings.ToLower(o.PhotoCol)]
+			if path == "" {
+				missing = append(missing, strconv.Itoa(points[i].Row))
+				continue
+			}
+			img, err := loadPhoto(filepath.Dir(spec.Input), path)
+			if err != nil {
+				logging.Warnf("row %d: %v", points[i].Row, err)
+				missing = append(missing, strconv.Itoa(points[i].Row))
+				continue
+			}
+			points[i].Photo = img
+		}
+		if len(missing) > 0 {
+			logging.Warnf("no photo for rows %s; using regular markers", strings.Join(missing, ", "))
+		}
+	}
+
+	if o.Clamp != "" {
+		lo, hi, err := timeline.ParseRange(o.Clamp)
+		if err != nil {
+			return nil, exit.UsageErrorf("invalid -clamp: %v", err)
+		}
+		below, above := timeline.ClampPoints(points, lo, hi)
+		for i, v := range rawValues {
+			rawValues[i] = math.Max(lo, math.Min(hi, v))
+		}
+		logging.Notef("Clamped %d point(s) above %g and %d point(s) below %g\n", above, hi, below, lo)
+	}
+
+	w, h := 12*vg.Inch, 8*vg.Inch // Larger size to accommodate labels
+	if o.AutoSize {
+		// Aim for ~60pt per labeled point at the default font size, scaled
+		// with the font, plus room for the axes.
+		n := 0
+		for i, pt := range points {
+			if o.labelRule.keep(i, pt) {
+				n++
+			}
+		}
+		want := vg.Points(float64(n)*60*o.LabelSize/9) + vg.Inch
+		w = min(max(w, want), vg.Length(o.MaxWidth)*vg.Inch)
+	}
+	if o.Width > 0 {
+		w = vg.Length(o.Width) * vg.Inch
+	}
+	if o.Height > 0 {
+		h = vg.Length(o.Height) * vg.Inch
+	}
+	if o.AutoSize || o.Width > 0 || o.Height > 0 {
+		logging.Notef("Canvas size: %.1fin x %.1fin\n", w/vg.Inch, h/vg.Inch)
+	}
+
+	adj, err := adjust.Positions(points, adjust.Options{
+		Mode:           o.Adjust,
+		Off:            o.NoAdjust,
+		DensityWindow:  o.DensityWindow,
+		Weighted:       o.DensityWeighted,
+		MaxCompression: o.MaxCompression,
+		OrderRepair:    o.OrderRepair,
+		MinSpacing:     o.MinSpacing,
+		Canvas:         w,
+		Months:         o.FractionalMonths,
+	})
+	if err != nil {
+		return nil, err
+	}
+	adjust.Log(spec.Output, adj.Adjustments)
+	adjustedPoints, densities, adjustments, sameYear := adj.Points, adj.Densities, adj.Adjustments, adj.SameYear
+
+	// Shorten labels where events are crowded; the console keeps the full
+	// text.
+	if o.AutoAbbreviate {
+		crowd := crowding(adjustedPoints)
+		for i := range adjustedPoints {
+			pt := &adjustedPoints[i]
+			if !pt.Tagged || float64(crowd[i]) < o.AbbreviateDensity {
+				continue
+			}
+			if short := abbreviate(pt.Label, o.AbbreviateLength); short != pt.Label {
+				logging.Notef("Abbreviated: '%s' -> '%s'\n", short, pt.Label)
+				pt.Label = short
+			}
+		}
+	}
+
+	// Build XY data and labels using adjusted points.
+	xy := make(plotter.XYs, len(adjustedPoints))
+	lbls := make(plotter.XYs, len(adjustedPoints))
+	labels := make([]string, len(adjustedPoints))
+	minYear := math.MaxFloat64
+	maxYear := -math.MaxFloat64
+	minY := 0.0
+	maxY := 0.0
+	dataMinY := math.MaxFloat64
+	dataMaxY := -math.MaxFloat64
+
+	for i, p := range adjustedPoints {
+		if o.Stems {
+			// Stem layout ignores values: every event sits on the axis.
+			p.Value = 0
+			p.Uncertainty = 0
+		}
+		xy[i].X = p.PlotX
+		xy[i].Y = p.Value
+		lbls[i].X = p.PlotX
+		lbls[i].Y = p.Value
+		labels[i] = p.Label
+
+		if p.PlotX < minYear {
+			minYear = p.PlotX
+		}
+		if p.PlotX > maxYear {
+			maxYear = p.PlotX
+		}
+		// Error bars count towards the range so padding includes them.
+		lo, hi := p.Value-p.Uncertainty, p.Value+p.Uncertainty
+		if lo < minY {
+			minY = lo
+		}
+		if hi > maxY {
+			maxY = hi
+		}
+		dataMinY = math.Min(dataMinY, lo)
+		dataMaxY = math.Max(dataMaxY, hi)
+	}
+
+	// Pad ranges a touch.
+	yPad := 0.6
+	if maxY-minY < 4 { // ensure some vertical breathing room
+		yPad = 1.0
+	}
+
+	p := plot.New()
+	if !o.NoTitle {
+		p.Title.Text = spec.Title
+	}
+	p.Title.TextStyle.Font.Size = vg.Points(o.TitleSize)
+	p.Title.TextStyle.Color = o.titleColor
+	p.Title.Text = wrapTitle(p.Title.Text, p.Title.TextStyle, w-2*vg.Points(titleMargin))
+	if err := alignTitle(p, o.TitleAlign, w); err != nil {
+		return nil, exit.UsageErrorf("invalid -title-align: %v", err)
+	}
+
+	// Configure x-axis based on flag
+	if o.ShowYears {
+		p.X.Label.Text = "Year"
+		p.X.Tick.Label.Font.Size = vg.Points(o.TickSize)
+		if sameYear {
+			// Positions are synthetic, so the year is shown once, centered.
+			y := adjustedPoints[0].Year
+			p.X.Tick.Marker = plot.ConstantTicks{{Value: y, Label: strconv.FormatFloat(y, 'f', -1, 64)}}
+		} else if o.TickEvery > 0 {
+			p.X.Tick.Marker = yearTicker{Map: adjust.NewYearMap(adjustedPoints), Every: o.TickEvery, Minor: o.MinorTicks}
+		} else {
+			p.X.Tick.Marker = autoYearTicker{
+				Map:       adjust.NewYearMap(adjustedPoints),
+				TextStyle: p.X.Tick.Label,
+				Width:     w - vg.Points(20),
+				Gap:       vg.Points(8),
+				MaxLabels: 20,
+			}
+		}
+	} else {
+		p.X.Label.Text = ""
+		// No ticks at all, so the layout reserves no room for them.
+		p.X.Tick.Marker = plot.ConstantTicks{}
+		p.X.Tick.Length = 0
+	}
+
+	p.Y.Label.Text = ""
+	if o.YTicks {
+		p.Y.Label.Text = o.YLabel
+	}
+	if o.ValueLabel != "" && p.Y.Label.Text == "" {
+		// The y-axis title is drawn rotated in the left margin even while the
+		// axis line itself stays invisible. Horizontal arrows point up and
+		// down once rotated.
+		p.Y.Label.Text = o.ValueLabel
+		if o.ValueArrows {
+			p.Y.Label.Text = "←   " + o.ValueLabel + "   →"
+		}
+		p.Y.Label.TextStyle.Color = color.Gray{Y: 120}
+	}
+
+	// Set x-axis range to start from the smallest year provided, or pad it
+	// explicitly when -pad-x is given.
+	xMin := math.Floor(minYear)
+	xMax := math.Ceil(maxYear)
+	if pad, ok, err := parsePad(o.PadX, maxYear-minYear); err != nil {
+		return nil, exit.UsageErrorf("invalid -pad-x: %v", err)
+	} else if ok {
+		xMin = minYear - pad
+		xMax = maxYear + pad
+	}
+	if pad := 0.01 * (maxYear - minYear); pad >= 1 && o.PadX == "" {
+		// On a timeline of a century or more, rounding to whole years
+		// leaves no visible margin, so pad by a share of the span instead.
+		xMin = math.Floor(minYear - pad)
+		xMax = math.Ceil(maxYear + pad)
+	}
+	if xMax-xMin < 2 && maxYear == minYear {
+		// A lone event (or a single year) sits centered in a two-year range.
+		xMin, xMax = minYear-1, maxYear+1
+	}
+	if sameYear {
+		xMin, xMax = minYear-1, maxYear+1
+	}
+	p.X.Min = xMin
+	p.X.Max = xMax
+	if o.Arrow {
+		// Leave room past the last event for the arrowhead so it isn't clipped.
+		extend := o.ArrowExtend
+		if extend <= 0 {
+			extend = math.Max(0.5, 0.03*(xMax-xMin))
+		}
+		p.X.Max = math.Max(xMax, maxYear+extend)
+	}
+
+	// A projection reaches past the data, so the x-range grows to include it.
+	var proj *projection
+	if o.Project != 0 {
+		last := adjustedPoints[len(adjustedPoints)-1]
+		slope, intercept, ok := linearFit(adjustedPoints)
+		switch {
+		case !ok:
+			logging.Warnf("-project needs events in at least two different years; skipping")
+		case o.Project <= last.Year:
+			return nil, exit.UsageErrorf("invalid -project %v: must be after the last event (%g)", o.Project, last.Year)
+		default:
+			proj = &projection{Year: o.Project, Value: slope*o.Project + intercept}
+			if o.Clamp != "" {
+				lo, hi, _ := timeline.ParseRange(o.Clamp)
+				if v := math.Max(lo, math.Min(hi, proj.Value)); v != proj.Value {
+					logging.Logf("Projection %.1f clamped to %g\n", proj.Value, v)
+					proj.Value, proj.Clamped = v, true
+				}
+			}
+			projX := adjust.NewYearMap(adjustedPoints).X(proj.Year)
+			p.X.Max = math.Max(p.X.Max, math.Ceil(projX)+0.5)
+		}
+	}
+
+	explicitY := false
+	switch o.YRange {
+	case "life":
+		// Ensure y shows both positive and negative; if your data is bounded -10..10 you can hardcode:
+		if minY > -10 {
+			minY = -10
+		}
+		if maxY < 10 {
+			maxY = 10
+		}
+		// Values far beyond the usual frame get padding to match.
+		yPad = math.Max(yPad, 0.03*(maxY-minY))
+		p.Y.Min = math.Floor(minY - yPad)
+		p.Y.Max = math.Ceil(maxY + yPad)
+	case "auto":
+		// Fit the data itself, padded by 5% of its range (or one unit when flat).
+		minY, maxY = dataMinY, dataMaxY
+		yPad = 0.05 * (maxY - minY)
+		if yPad == 0 {
+			yPad = 1
+		}
+		p.Y.Min = minY - yPad
+		p.Y.Max = maxY + yPad
+	default:
+		lo, hi, err := timeline.ParseRange(o.YRange)
+		if err != nil {
+			return nil, exit.UsageErrorf("invalid -y-range: %v (or use life or auto)", err)
+		}
+		minY, maxY = lo, hi
+		p.Y.Min, p.Y.Max = lo, hi
+		explicitY = true
+	}
+	if pad, ok, err := parsePad(o.PadY, maxY-minY); err != nil {
+		return nil, exit.UsageErrorf("invalid -pad-y: %v", err)
+	} else if ok {
+		p.Y.Min = minY - pad
+		p.Y.Max = maxY + pad
+	}
+	// A -baseline reference follows the same x mapping and widens the
+	// y-range only as far as needed to keep it in view.
+	var baseXY plotter.XYs
+	if o.Baseline != "" {
+		ref, err := timeline.Load(o.Baseline)
+		if err != nil {
+			return nil, exit.InputError(fmt.Errorf("-baseline %s: %w", o.Baseline, err))
+		}
+		ym := adjust.NewYearMap(adjustedPoints)
+		for _, pt := range ref {
+			baseXY = append(baseXY, plotter.XY{X: ym.X(pt.Year), Y: pt.Value})
+		}
+		baseXY = clipXYs(baseXY, p.X.Min, p.X.Max)
+		for _, xy := range baseXY {
+			if !explicitY {
+				p.Y.Min = math.Min(p.Y.Min, math.Floor(xy.Y-yPad))
+				p.Y.Max = math.Max(p.Y.Max, math.Ceil(xy.Y+yPad))
+			}
+		}
+		if len(baseXY) < 2 {
+			logging.Warnf("-baseline %s has fewer than two points in the plotted years", o.Baseline)
+		}
+	}
+	// Split charts reuse the frame of the combined chart.
+	if spec.Shared != nil {
+		p.X.Min, p.X.Max = spec.Shared.XMin, spec.Shared.XMax
+		p.Y.Min, p.Y.Max = spec.Shared.YMin, spec.Shared.YMax
+		explicitY = true
+	}
+	yRangeMin, yRangeMax := p.Y.Min, p.Y.Max
+
+	// Hide y-axis tick labels and marks unless asked for
+	if !o.YTicks {
+		p.Y.Tick.Label.Font.Size = 0
+		p.Y.Tick.Length = 0
+	}
+
+	// Chapter bands go first so they render beneath the grid and data.
+	if o.Chapters != "" {
+		chs, err := readChapters(o.Chapters)
+		if err != nil {
+			return nil, exit.InputError(err)
+		}
+		p.Add(newChapterBands(chs, adjust.NewYearMap(adjustedPoints)))
+	}
+
+	// Streaks are measured in original years but shaded at their
+	// plotted positions; interpolated years don't count as events.
+	if o.Streaks > 0 {
+		events := timeline.Observed(adjustedPoints)
+		streaks := timeline.FindStreaks(events, o.Streaks)
+		p.Add(newStreakShade(events, streaks, withAlpha(o.pal.Positive, 28), withAlpha(o.pal.Negative, 28), o.StreakLabels))
+		logging.Logf("Shaded %d streak(s) of %g year(s) or more\n", len(streaks), o.Streaks)
+	}
+
+	// The group band sits behind everything drawn from the input itself.
+	if o.Band != "" {
+		files, err := timeline.BandFiles(o.Band)
+		if err != nil {
+			return nil, exit.UsageErrorf("invalid -band: %v", err)
+		}
+		var series []map[float64]float64
+		for _, f := range files {
+			pts, err := timeline.ReadCSV(f)
+			if err != nil {
+				return nil, exit.InputError(fmt.Errorf("%s: %w", f, err))
+			}
+			if _, err := o.normalizeValues(f, pts); err != nil {
+				return nil, err
+			}
+			o.invertValues(f, pts)
+			series = append(series, timeline.YearlySeries(pts))
+		}
+		rows := timeline.PercentileBand(series, o.BandMin)
+		if len(rows) == 0 {
+			logging.Warnf("no year has data from at least %d of the %d -band series", o.BandMin, len(series))
+		}
+		ym := adjust.NewYearMap(adjustedPoints)
+		for _, run := range timeline.BandRuns(rows) {
+			poly := make(plotter.XYs, 0, 2*len(run))
+			median := make(plotter.XYs, len(run))
+			for i, r := range run {
+				poly = append(poly, plotter.XY{X: ym.X(r.Year), Y: r.P75})
+				median[i] = plotter.XY{X: ym.X(r.Year), Y: r.Median}
+			}
+			for i := len(run) - 1; i >= 0; i-- {
+				poly = append(poly, plotter.XY{X: ym.X(run[i].Year), Y: run[i].P25})
+			}
+			if len(run) > 1 {
+				band, err := plotter.NewPolygon(poly)
+				if err != nil {
+					return nil, exit.RenderError(err)
+				}
+				band.Color = withAlpha(o.pal.Line, 45)
+				band.LineStyle.Width = 0
+				p.Add(band)
+			}
+			line, err := plotter.NewLine(median)
+			if err != nil {
+				return nil, exit.RenderError(err)
+			}
+			line.Color = withAlpha(o.pal.Line, 140)
+			line.Width = vg.Points(1)
+			line.Dashes = []vg.Length{vg.Points(4), vg.Points(2)}
+			p.Add(line)
+		}
+		logging.Logf("Band: %d series, %d year(s) with at least %d\n", len(series), len(rows), o.BandMin)
+	}
+
+	// Optional grid for readability.
+	if o.Grid != "off" {
+		grid := plotter.NewGrid()
+		grid.Horizontal.Color = color.Gray{Y: 230}
+		grid.Vertical.Color = color.Gray{Y: 245}
+		if o.gridColor != nil {
+			grid.Horizontal.Color = o.gridColor
+			grid.Vertical.Color = o.gridColor
+		}
+		if o.GridStyle == "dotted" {
+			dots := []vg.Length{vg.Points(1), vg.Points(2)}
+			grid.Horizontal.Dashes = dots
+			grid.Vertical.Dashes = dots
+		}
+		// A nil color tells the grid plotter to skip that direction.
+		if o.Grid == "horizontal" {
+			grid.Vertical.Color = nil
+		}
+		if o.Grid == "vertical" {
+			grid.Horizontal.Color = nil
+		}
+		p.Add(grid)
+	}
+
+	// Sign shading sits beneath everything drawn from the data.
+	if o.ShadeSign {
+		if o.ShadeOpacity < 0 || o.ShadeOpacity > 1 {
+			return nil, exit.UsageErrorf("invalid -shade-opacity %v: must be between 0 and 1", o.ShadeOpacity)
+		}
+		pos, neg := o.pal.Positive, o.pal.Negative
+		if o.ShadePositive != "" {
+			if pos, err = parseColor(o.ShadePositive); err != nil {
+				return nil, exit.UsageErrorf("invalid -shade-positive: %v", err)
+			}
+		}
+		if o.ShadeNegative != "" {
+			if neg, err = parseColor(o.ShadeNegative); err != nil {
+				return nil, exit.UsageErrorf("invalid -shade-negative: %v", err)
+			}
+		}
+		alpha := uint8(o.ShadeOpacity * 255)
+		p.Add(&signShade{
+			XYs:      xy,
+			Positive: withAlpha(pos, alpha),
+			Negative: withAlpha(neg, alpha),
+		})
+
+		// Measure time above zero in original years, not adjusted positions.
+		orig := make(plotter.XYs, len(adjustedPoints))
+		for i, pt := range adjustedPoints {
+			orig[i] = plotter.XY{X: pt.Year, Y: pt.Value}
+		}
+		logging.Logf("%.0f%% of the line above zero\n", 100*fractionAboveZero(orig))
+	}
+
+	// The baseline and its shading sit beneath the line as well.
+	if len(baseXY) > 1 {
+		if o.BaselineShade && !o.Stems {
+			p.Add(&betweenShade{Line: xy, Base: baseXY, Positive: withAlpha(o.pal.Positive, 60), Negative: withAlpha(o.pal.Negative, 60)})
+		}
+		base, err := plotter.NewLine(baseXY)
+		if err != nil {
+			return nil, exit.RenderError(err)
+		}
+		base.Color = color.Gray{Y: 150}
+		base.Width = vg.Points(o.LineWidth)
+		base.Dashes = []vg.Length{vg.Points(6), vg.Points(4)}
+		p.Add(base)
+	}
+
+	// Uncertainty bars sit beneath the line and markers.
+	bars, err := newUncertaintyBars(adjustedPoints, withAlpha(o.pal.Line, 110))
+	if err != nil {
+		return nil, exit.RenderError(err)
+	}
+	if bars != nil {
+		p.Add(bars)
+	}
+
+	// Events after -now are planned rather than lived.
+	future := func(i int) bool { return adjustedPoints[i].Year > o.nowYear }
+
+	// Individual events behind their -bucket bins.
+	if o.BucketRaw && len(rawEvents) > 0 && !o.Stems {
+		ym := adjust.NewYearMap(adjustedPoints)
+		raw := make(plotter.XYs, len(rawEvents))
+		for i, pt := range rawEvents {
+			raw[i] = plotter.XY{X: ym.X(pt.Year), Y: pt.Value}
+		}
+		sc, err := plotter.NewScatter(clipXYs(raw, p.X.Min, p.X.Max))
+		if err != nil {
+			return nil, exit.RenderError(err)
+		}
+		sc.GlyphStyle = draw.GlyphStyle{Color: withAlpha(o.pal.Marker, 90), Radius: vg.Points(2), Shape: draw.CircleGlyph{}}
+		p.Add(sc)
+	}
+
+	// Raw values behind a smoothed line, so nothing is hidden.
+	if rawValues != nil && !o.Stems {
+		raw := make(plotter.XYs, len(rawValues))
+		for i, v := range rawValues {
+			raw[i] = plotter.XY{X: adjustedPoints[i].PlotX, Y: v}
+		}
+		sc, err := plotter.NewScatter(raw)
+		if err != nil {
+			return nil, exit.RenderError(err)
+		}
+		sc.GlyphStyle = draw.GlyphStyle{Color: withAlpha(o.pal.Marker, 90), Radius: vg.Points(2), Shape: draw.CircleGlyph{}}
+		p.Add(sc)
+	}
+
+	// Line connecting points. In river mode its width follows the local density.
+	// The plain line splits at -now and continues dashed and faded.
+	lineColor := o.pal.Line
+	if o.Stems {
+		// The zero axis line stands in for the connecting line.
+	} else if len(xy) < 2 {
+		// A single event has nothing to connect to.
+	} else if o.River {
+		p.Add(newRiverLine(xy, densities, vg.Points(1), vg.Points(7), lineColor))
+	} else {
+		addLine := func(xys plotter.XYs, dashes []vg.Length, c color.Color) error {
+			line, err := plotter.NewLine(xys)
+			if err != nil {
+				return err
+			}
+			line.Width = vg.Points(o.LineWidth)
+			line.Dashes = dashes
+			line.Color = c
+			p.Add(line)
+			return nil
+		}
+		runs := []lineRun{{XYs: xy}}
+		if o.GapYears > 0 {
+			years := make([]float64, len(adjustedPoints))
+			for i, pt := range adjustedPoints {
+				years[i] = pt.Year
+			}
+			runs = splitGaps(xy, years, o.GapYears)
+		}
+		nowX := adjust.NewYearMap(adjustedPoints).X(o.nowYear)
+		for _, run := range runs {
+			if run.Gap && o.GapStyle == "break" {
+				for j := 1; j < len(run.XYs); j++ {
+					a, b := run.XYs[j-1], run.XYs[j]
+					c := color.Color(lineColor)
+					if (a.X+b.X)/2 > nowX {
+						c = faded(lineColor)
+					}
+					p.Add(gapMark{X: (a.X + b.X) / 2, Y: (a.Y + b.Y) / 2, Color: c})
+				}
+				continue
+			}
+			lived, planned := splitAt(run.XYs, nowX)
+			livedDashes, plannedDashes := o.lineDashes, futureDashes
+			if run.Gap {
+				livedDashes, plannedDashes = gapDashes, gapDashes
+			}
+			if len(lived) > 1 {
+				if err := addLine(lived, livedDashes, lineColor); err != nil {
+					return nil, exit.RenderError(err)
+				}
+			}
+			if len(planned) > 1 {
+				if err := addLine(planned, plannedDashes, faded(lineColor)); err != nil {
+					return nil, exit.RenderError(err)
+				}
+			}
+		}
+	}
+
+	// The running sum sits beneath the markers on its own scale.
+	if o.Cumulative && !o.Stems {
+		xs := make([]float64, len(adjustedPoints))
+		for i, pt := range adjustedPoints {
+			xs[i] = pt.PlotX
+		}
+		cc := newCumulativeCurve(adjustedPoints, xs)
+		p.Add(cc)
+		p.Legend.Add("cumulative", cc)
+	}
+
+	// The projection is speculative: dashed, faded, and captioned as such.
+	if proj != nil {
+		i := len(adjustedPoints) - 1
+		end := plotter.XY{X: adjust.NewYearMap(adjustedPoints).X(proj.Year), Y: proj.Value}
+		line, err := plotter.NewLine(plotter.XYs{{X: adjustedPoints[i].PlotX, Y: xy[i].Y}, end})
+		if err != nil {
+			return nil, exit.RenderError(err)
+		}
+		line.Color = faded(o.pal.Line)
+		line.Width = vg.Points(o.LineWidth)
+		line.Dashes = futureDashes
+		p.Add(line)
+
+		mark, err := plotter.NewScatter(plotter.XYs{end})
+		if err != nil {
+			return nil, exit.RenderError(err)
+		}
+		mark.GlyphStyle.Color = faded(o.pal.Marker)
+		mark.GlyphStyle.Radius = vg.Points(3)
+		p.Add(mark)
+
+		lbl, err := plotter.NewLabels(plotter.XYLabels{XYs: plotter.XYs{end}, Labels: []string{proj.Label()}})
+		if err != nil {
+			return nil, exit.RenderError(err)
+		}
+		sty := &lbl.TextStyle[0]
+		sty.Font.Size = vg.Points(o.LabelSize)
+		sty.Font.Style = xfont.StyleItalic
+		sty.Color = color.Gray{Y: 120}
+		sty.XAlign = draw.XRight
+		lbl.Offset = vg.Point{X: -vg.Points(6), Y: vg.Points(6)}
+		p.Add(lbl)
+	}
+
+	// Rate-of-change captions at segment midpoints.
+	if o.Slopes && !o.Stems {
+		xs := make([]float64, len(adjustedPoints))
+		for i, pt := range adjustedPoints {
+			xs[i] = pt.PlotX
+		}
+		if notes := findSlopes(adjustedPoints, xs, o.SlopeThreshold); len(notes) > 0 {
+			p.Add(newSlopeNotes(notes, o.pal.Positive, o.pal.Negative))
+		}
+	}
+
+	// Scatter points.
+	sc, err := plotter.NewScatter(xy)
+	if err != nil {
+		return nil, exit.RenderError(err)
+	}
+	sc.Radius = vg.Points(3)
+	sc.GlyphStyle.Color = o.pal.Marker
+	sc.GlyphStyleFunc = func(i int) draw.GlyphStyle {
+		sty := sc.GlyphStyle
+		if cs, ok := catStyles[adjustedPoints[i].Category]; ok {
+			sty.Color = cs.Color
+			sty.Shape = cs.Shape
+		}
+		if o.PolarityStyle == "shape" {
+			sty.Shape = polarityShapes[adjustedPoints[i].Polarity()]
+		}
+		if off := adjustedPoints[i].OffScale; off != 0 {
+			// Clamped points get an open triangle pointing off the scale.
+			sty.Shape = offScaleGlyph{Down: off < 0}
+			sty.Radius = vg.Points(4.5)
+		} else if img := adjustedPoints[i].Photo; img != nil {
+			sty.Shape = photoGlyph{Img: img}
+			sty.Radius = vg.Points(o.PhotoSize / 2)
+		} else if adjustedPoints[i].Highlight {
+			sty = emphasizeGlyph(sty, o.pal.Highlight)
+		} else if n := adjustedPoints[i].Count; n > 1 {
+			sty.Radius = bucketRadius(sty.Radius, n)
+		} else if adjustedPoints[i].Interpolated {
+			sty.Shape = draw.RingGlyph{}
+			sty.Radius = vg.Points(2)
+		}
+		if future(i) {
+			sty.Color = faded(sty.Color)
+		}
+		return sty
+	}
+	p.Add(sc)
+
+	// A legend keys each category's marker.
+	for _, name := range categories {
+		cs := catStyles[name]
+		p.Legend.Add(name, legendGlyph{Style: draw.GlyphStyle{Color: cs.Color, Shape: cs.Shape, Radius: sc.Radius}})
+	}
+	if inverted {
+		p.Legend.Add("values inverted (up = lower in the data)")
+	}
+	if autoScaled {
+		p.Legend.Add(fmt.Sprintf("values scaled %s", timeline.PercentScale))
+	}
+	if o.Interpolate && len(timeline.Observed(adjustedPoints)) < len(adjustedPoints) {
+		p.Legend.Add("interpolated", legendGlyph{Style: draw.GlyphStyle{Color: o.pal.Marker, Shape: draw.RingGlyph{}, Radius: vg.Points(2)}})
+	}
+	p.Legend.Top = true
+	p.Legend.TextStyle.Font.Size = vg.Points(o.LabelSize)
+
+	// Labels (captions) next to each point with alternating positions to avoid overlap.
+	// Points filtered out by the label rule keep their markers but get no caption,
+	// and the alternation only counts labeled points so the pattern stays intact.
+	inlineLabels := !o.Stems && o.LabelLanes == 0
+	view := newPlotView(p, w, h)
+	labeled := 0
+	// Estimated caption and marker boxes, checked for overlaps afterwards,
+	// and the captions' boxes relative to their points.
+	var placed, markers []placedLabel
+	var captions []anchoredBox
+	markerBox := func(at vg.Point) vg.Rectangle {
+		r := sc.Radius
+		return vg.Rectangle{Min: at.Sub(vg.Point{X: r, Y: r}), Max: at.Add(vg.Point{X: r, Y: r})}
+	}
+	for i, point := range adjustedPoints {
+		at := view.at(point.PlotX, point.Value)
+		markers = append(markers, placedLabel{Text: point.Label, Year: point.Year, Marker: markerBox(at)})
+		captioned := inlineLabels && o.labelRule.keep(i, point)
+		labelAbove := true
+		if captioned {
+			slot := labeled
+			labeled++
+
+			labelData := plotter.XYLabels{
+				XYs:    plotter.XYs{{X: point.PlotX, Y: point.Value}},
+				Labels: []string{point.Label},
+			}
+			l, err := plotter.NewLabels(labelData)
+			if err != nil {
+				return nil, exit.RenderError(err)
+			}
+
+			// Alternate label positions: above/below and left/right to reduce overlap.
+			// Offsets scale with the font so larger text doesn't sit on its marker.
+			xOffset := vg.Points(8 * o.LabelSize / 9)
+			yOffset := vg.Points(8 * o.LabelSize / 9)
+			if point.Photo != nil {
+				// Keep captions clear of the photo, which is much larger than a marker.
+				xOffset = max(xOffset, vg.Points(o.PhotoSize/2+3))
+				yOffset = max(yOffset, vg.Points(o.PhotoSize/2+3))
+			}
+
+			// Put the label on the convex side of the line through the point,
+			// alternating top-right, bottom-right, top-left, bottom-left on ties.
+			if !o.Stems {
+				var neighbors []vg.Point
+				if i > 0 {
+					neighbors = append(neighbors, view.at(adjustedPoints[i-1].PlotX, xy[i-1].Y))
+				}
+				if i+1 < len(adjustedPoints) {
+					neighbors = append(neighbors, view.at(adjustedPoints[i+1].PlotX, xy[i+1].Y))
+				}
+				slot = pickQuadrant(view.at(point.PlotX, point.Value), neighbors, slot%4)
+			}
+			switch slot % 4 {
+			case 0: // top-right
+				l.Offset = vg.Point{X: xOffset, Y: yOffset}
+			case 1: // bottom-right
+				l.Offset = vg.Point{X: xOffset, Y: -yOffset}
+				labelAbove = false
+			case 2: // top-left
+				l.Offset = vg.Point{X: -xOffset, Y: yOffset}
+			case 3: // bottom-left
+				l.Offset = vg.Point{X: -xOffset, Y: -yOffset}
+				labelAbove = false
+			}
+
+			l.TextStyle[0].Font.Size = vg.Points(o.LabelSize)
+			if point.Highlight {
+				l.TextStyle[0] = emphasize(l.TextStyle[0])
+			}
+			if future(i) {
+				l.TextStyle[0].Font.Style = xfont.StyleItalic
+				l.TextStyle[0].Color = color.Gray{Y: 120}
+			}
+
+			// Labels that would run off the plot flip to the opposite side,
+			// measured as drawn there, tilted by -label-angle.
+			l.Offset = view.flipIntoView(view.at(point.PlotX, point.Value), l.Offset, l.TextStyle[0], point.Label, o.LabelAngle)
+			l.TextStyle[0] = orientLabel(l.TextStyle[0], l.Offset, o.LabelAngle)
+			labelAbove = l.Offset.Y > 0
+
+			rel := l.TextStyle[0].Rectangle(point.Label).Add(l.Offset)
+			captions = append(captions, anchoredBox{X: point.PlotX, Y: point.Value, Rel: rel})
+			placed = append(placed, placedLabel{Text: point.Label, Year: point.Year, Box: rel.Add(at)})
+			p.Add(l)
+		}
+
+		// The raw value goes on the opposite side of the marker from the
+		// caption. Auto-generated captions already contain it.
+		if o.ShowValues && inlineLabels && !(captioned && !point.Tagged) {
+			v, err := plotter.NewLabels(plotter.XYLabels{
+				XYs:    plotter.XYs{{X: point.PlotX, Y: point.Value}},
+				Labels: []string{fmt.Sprintf(o.ValueFormat, point.Value)},
+			})
+			if err != nil {
+				return nil, exit.RenderError(err)
+			}
+			sty := &v.TextStyle[0]
+			sty.Font.Size = vg.Points(o.LabelSize * 0.75)
+			sty.Color = color.Gray{Y: 110}
+			sty.XAlign = draw.XCenter
+			gap := vg.Points(5)
+			if captioned && labelAbove {
+				sty.YAlign = draw.YTop
+				v.Offset = vg.Point{Y: -gap}
+			} else {
+				sty.YAlign = draw.YBottom
+				v.Offset = vg.Point{Y: gap}
+			}
+			p.Add(v)
+		}
+	}
+
+	// Captions that flipping couldn't bring into view pad the axes
+	// instead, unless the ranges are fixed or shared with other charts.
+	if spec.Shared == nil && view.widenToFit(captions, o.PadX == "", !explicitY && o.PadY == "") {
+		p.X.Min, p.X.Max, p.Y.Min, p.Y.Max = view.xMin, view.xMax, view.yMin, view.yMax
+		for i := range placed {
+			placed[i].Box = captions[i].Rel.Add(view.at(captions[i].X, captions[i].Y))
+		}
+		for i, point := range adjustedPoints {
+			markers[i].Marker = markerBox(view.at(point.PlotX, point.Value))
+		}
+		logging.Logf("Widened the axes to x %.1f to %.1f, y %.1f to %.1f to fit the labels\n", p.X.Min, p.X.Max, p.Y.Min, p.Y.Max)
+	}
+
+	overlaps := labelOverlaps(placed, markers)
+	reportOverlaps(overlaps)
+
+	// Draw custom x-axis along y=0, as long as zero is within the y-range.
+	originY := 0.0
+	drawOrigin := p.Y.Min <= originY && originY <= p.Y.Max
+
+	if drawOrigin {
+		// x-axis along y=0 across the full x range
+		xAxisXY := make(plotter.XYs, 2)
+		xAxisXY[0].X, xAxisXY[0].Y = p.X.Min, originY
+		xAxisXY[1].X, xAxisXY[1].Y = p.X.Max, originY
+		xAxisLine, _ := plotter.NewLine(xAxisXY)
+		xAxisLine.Color = o.axisColor
+		xAxisLine.Width = vg.Points(1.0)
+		p.Add(xAxisLine)
+		if o.Arrow {
+			p.Add(arrowHead{
+				X: p.X.Max, Y: originY,
+				Color:  xAxisLine.Color,
+				Length: vg.Points(8),
+				Width:  vg.Points(6),
+			})
+		}
+
+		// Decade tick marks along the zero line, positioned through the adjustment.
+		if o.Decades || o.DecadeLabels {
+			ym := adjust.NewYearMap(adjustedPoints)
+			p.Add(newDecadeTicks(ym, adjustedPoints[0].Year, adjustedPoints[len(adjustedPoints)-1].Year, p.X.Min, p.X.Max, o.DecadeLabels))
+		}
+
+		// Captions for long quiet stretches, found in original years.
+		if o.AnnotateGaps {
+			years := make([]float64, len(adjustedPoints))
+			for i, pt := range adjustedPoints {
+				years[i] = pt.Year
+			}
+			if gaps := findQuietGaps(years, adjust.NewYearMap(adjustedPoints), o.QuietYears); len(gaps) > 0 {
+				p.Add(newQuietLabels(gaps))
+			}
+		}
+	}
+
+	if o.MeanLine && !o.Stems {
+		p.Add(newMeanLine(mean))
+	}
+
+	// Callouts go above the data and regular labels.
+	if len(o.Callouts) > 0 {
+		var items []callout
+		for _, cf := range o.Callouts {
+			year, txt, err := parseCallout(cf)
+			if err != nil {
+				return nil, exit.UsageErrorf("invalid -callout: %v", err)
+			}
+			i := nearestPoint(adjustedPoints, year)
+			items = append(items, callout{X: adjustedPoints[i].PlotX, Y: xy[i].Y, Text: txt})
+		}
+		p.Add(newCallouts(items))
+	}
+
+	// Stem labels go on top of the zero axis line they hang from.
+	if o.Stems {
+		var xs []float64
+		var texts []string
+		for i, point := range adjustedPoints {
+			if o.labelRule.keep(i, point) {
+				xs = append(xs, point.PlotX)
+				texts = append(texts, point.Label)
+			}
+		}
+		p.Add(newStemLabels(xs, texts, vg.Points(o.LabelSize)))
+	}
+
+	// Configure axis colors based on flag
+	if o.ShowYears {
+		// Make the x-axis light grey when showing years
+		p.X.Color = color.RGBA{A: 255, R: 200, G: 200, B: 200} // Light grey
+	} else {
+		// Make the x-axis invisible when not showing years
+		p.X.Color = color.RGBA{A: 0, R: 0, G: 0, B: 0} // Invisible
+	}
+	if o.YTicks {
+		// Light grey like the zero line; the zero line stays the only horizontal axis.
+		p.Y.Color = color.RGBA{A: 255, R: 200, G: 200, B: 200}
+	} else {
+		p.Y.Color = color.RGBA{A: 0, R: 0, G: 0, B: 0} // Make y-axis invisible
+	}
+
+	// Adding plotters grows the axes to fit their data; an explicit
+	// y-range should win over that.
+	if explicitY {
+		p.Y.Min, p.Y.Max = yRangeMin, yRangeMax
+	}
+	if spec.Shared != nil {
+		p.X.Min, p.X.Max = spec.Shared.XMin, spec.Shared.XMax
+	}
+	frame := Frame{XMin: p.X.Min, XMax: p.X.Max, YMin: p.Y.Min, YMax: p.Y.Max}
+
+	// Span strip: a Gantt-style band reserved below the main chart.
+	if o.SpanLane {
+		ym := adjust.NewYearMap(adjustedPoints)
+		var bars []spanBar
+		for _, pt := range adjustedPoints {
+			if pt.End > 0 {
+				bars = append(bars, spanBar{X0: pt.PlotX, X1: ym.X(pt.End), Label: pt.Label})
+			}
+		}
+		placed, dropped := assignSpanLanes(bars, maxSpanLanes)
+		for _, b := range dropped {
+			logging.Warnf("span '%s' left out of the span strip (more than %d overlapping spans)", b.Label, maxSpanLanes)
+		}
+		if len(placed) > 0 {
+			lanes := 0
+			for _, b := range placed {
+				lanes = max(lanes, b.Lane+1)
+			}
+			laneHeight := 0.06 * (p.Y.Max - p.Y.Min)
+			top := p.Y.Min
+			p.Y.Min -= float64(lanes) * laneHeight
+			p.Add(newSpanStrip(placed, top, laneHeight, withAlpha(o.pal.Line, 160)))
+		}
+	}
+
+	// Label lanes: bands reserved at the top and bottom of the data area.
+	// The canvas grows taller when the requested lanes can't fit every label.
+	if o.LabelLanes > 0 {
+		var lls []laneLabel
+		for i, point := range adjustedPoints {
+			if o.labelRule.keep(i, point) {
+				lls = append(lls, laneLabel{X: point.PlotX, Y: xy[i].Y, Label: point.Label, Top: xy[i].Y >= 0})
+			}
+		}
+		ll := newLabelLanes(lls, vg.Points(o.LabelSize))
+
+		// Estimate the data area to see how many lanes each band needs.
+		plotW := w - vg.Points(20)
+		trX := func(x float64) vg.Length { return plotW * vg.Length((x-p.X.Min)/(p.X.Max-p.X.Min)) }
+		_, needTop := ll.layout(trX, true)
+		_, needBottom := ll.layout(trX, false)
+		top, bottom := max(o.LabelLanes, needTop), max(o.LabelLanes, needBottom)
+		if top+bottom > 2*o.LabelLanes {
+			h += vg.Length(top+bottom-2*o.LabelLanes) * ll.LaneHeight
+			logging.Notef("Label lanes: %d above and %d below needed (%d requested); canvas height increased to %.1fin\n",
+				needTop, needBottom, o.LabelLanes, h/vg.Inch)
+		}
+
+		// Grow the y-range so the lanes occupy their share of the data area.
+		dataH := h - vg.Points(40)
+		fTop := float64(vg.Length(top) * ll.LaneHeight / dataH)
+		fBottom := float64(vg.Length(bottom) * ll.LaneHeight / dataH)
+		span := (p.Y.Max - p.Y.Min) / (1 - fTop - fBottom)
+		p.Y.Max += fTop * span
+		p.Y.Min -= fBottom * span
+		p.Add(ll)
+	}
+
+	var footer *Footer
+	if spec.Footer != "" {
+		sty := p.X.Tick.Label
+		sty.Font.Size = vg.Points(o.TickSize)
+		sty.Color = o.axisColor
+		footer = &Footer{Text: spec.Footer, Style: sty}
+	}
+	return &Chart{
+		Plot: p, W: w, H: h, Footer: footer, Frame: frame,
+		Points: adjustedPoints, Adjustments: adjustments, Overlaps: overlaps,
+	}, nil
+}
+
+// Frame is the x and y range of a finished chart.
+type Frame struct {
+	XMin, XMax float64
+	YMin, YMax float64
+}
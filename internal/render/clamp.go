@@ -0,0 +1,31 @@
+package render
+
+import (
+	"math"
+
+	"gonum.org/v1/plot/vg"
+	"gonum.org/v1/plot/vg/draw"
+)
+
+// offScaleGlyph draws an open triangle pointing up, or down when Down is
+// set, to mark a point whose value was clamped to the edge of the scale.
+type offScaleGlyph struct {
+	Down bool
+}
+
+// DrawGlyph implements the draw.GlyphDrawer interface.
+func (g offScaleGlyph) DrawGlyph(c *draw.Canvas, sty draw.GlyphStyle, pt vg.Point) {
+	if !g.Down {
+		draw.TriangleGlyph{}.DrawGlyph(c, sty, pt)
+		return
+	}
+	c.SetLineStyle(draw.LineStyle{Color: sty.Color, Width: vg.Points(0.5)})
+	sin, cos := math.Sincos(math.Pi / 6)
+	r := sty.Radius + (sty.Radius-sty.Radius*vg.Length(sin))/2
+	p := make(vg.Path, 0, 4)
+	p.Move(vg.Point{X: pt.X, Y: pt.Y - r})
+	p.Line(vg.Point{X: pt.X - r*vg.Length(cos), Y: pt.Y + r*vg.Length(sin)})
+	p.Line(vg.Point{X: pt.X + r*vg.Length(cos), Y: pt.Y + r*vg.Length(sin)})
+	p.Close()
+	c.Stroke(p)
+}
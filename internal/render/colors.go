@@ -0,0 +1,49 @@
+package render
+
+import (
+	"fmt"
+	"image/color"
+	"strconv"
+	"strings"
+)
+
+// namedColors are the color names accepted by color flags in addition to
+// hex strings.
+var namedColors = map[string]color.RGBA{
+	"black":     {A: 255},
+	"white":     {A: 255, R: 255, G: 255, B: 255},
+	"grey":      {A: 255, R: 128, G: 128, B: 128},
+	"gray":      {A: 255, R: 128, G: 128, B: 128},
+	"lightgrey": {A: 255, R: 200, G: 200, B: 200},
+	"lightgray": {A: 255, R: 200, G: 200, B: 200},
+	"red":       {A: 255, R: 220, G: 50, B: 47},
+	"green":     {A: 255, R: 60, G: 160, B: 60},
+	"blue":      {A: 255, R: 38, G: 110, B: 200},
+	"lightblue": {A: 255, R: 100, G: 150, B: 200},
+	"orange":    {A: 255, R: 240, G: 140, B: 20},
+	"purple":    {A: 255, R: 130, G: 70, B: 170},
+}
+
+// parseColor parses a color given as #rgb, #rrggbb, #rrggbbaa (the leading
+// '#' is optional) or as one of the namedColors.
+func parseColor(s string) (color.RGBA, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if c, ok := namedColors[s]; ok {
+		return c, nil
+	}
+	hex := strings.TrimPrefix(s, "#")
+	if len(hex) == 3 {
+		hex = string([]byte{hex[0], hex[0], hex[1], hex[1], hex[2], hex[2]})
+	}
+	if len(hex) == 6 {
+		hex += "ff"
+	}
+	if len(hex) != 8 {
+		return color.RGBA{}, fmt.Errorf("invalid color %q: use #rrggbb, #rrggbbaa or a name", s)
+	}
+	v, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil {
+		return color.RGBA{}, fmt.Errorf("invalid color %q: use #rrggbb, #rrggbbaa or a name", s)
+	}
+	return color.RGBA{R: uint8(v >> 24), G: uint8(v >> 16), B: uint8(v >> 8), A: uint8(v)}, nil
+}
@@ -0,0 +1,84 @@
+package render
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+
+	"gonum.org/v1/plot"
+	"gonum.org/v1/plot/font"
+	"gonum.org/v1/plot/plotter"
+	"gonum.org/v1/plot/vg"
+	"gonum.org/v1/plot/vg/draw"
+
+	"github.com/rojaswestall/lifeline/internal/timeline"
+)
+
+// cumulativeCurve overlays the running sum of the values. It has its own
+// y scale, fitted to the full height of the plot, so it never disturbs the
+// primary range; the extremes are captioned at the right edge.
+type cumulativeCurve struct {
+	XYs    plotter.XYs // x as plotted, y as the running sum
+	Lo, Hi float64     // secondary y range
+
+	LineStyle draw.LineStyle
+	TextStyle draw.TextStyle
+}
+
+// newCumulativeCurve sums pts in chronological order, placing each sum at
+// the matching x in xs.
+func newCumulativeCurve(pts []timeline.Point, xs []float64) *cumulativeCurve {
+	cc := &cumulativeCurve{
+		XYs: make(plotter.XYs, len(pts)),
+		Lo:  math.Inf(1),
+		Hi:  math.Inf(-1),
+		LineStyle: draw.LineStyle{
+			Color: color.NRGBA{A: 140, R: 130, G: 70, B: 170},
+			Width: vg.Points(1),
+		},
+		TextStyle: draw.TextStyle{
+			Color:   color.NRGBA{A: 180, R: 130, G: 70, B: 170},
+			Font:    font.From(plot.DefaultFont, vg.Points(7)),
+			XAlign:  draw.XRight,
+			YAlign:  draw.YCenter,
+			Handler: plot.DefaultTextHandler,
+		},
+	}
+	sum := 0.0
+	for i, p := range pts {
+		sum += p.Value
+		cc.XYs[i] = plotter.XY{X: xs[i], Y: sum}
+		cc.Lo = math.Min(cc.Lo, sum)
+		cc.Hi = math.Max(cc.Hi, sum)
+	}
+	// Keep zero in range so the curve's sign stays readable.
+	cc.Lo = math.Min(cc.Lo, 0)
+	cc.Hi = math.Max(cc.Hi, 0)
+	if cc.Hi == cc.Lo {
+		cc.Hi = cc.Lo + 1
+	}
+	return cc
+}
+
+// Plot implements the plot.Plotter interface.
+func (cc *cumulativeCurve) Plot(c draw.Canvas, plt *plot.Plot) {
+	trX, _ := plt.Transforms(&c)
+	trY := func(v float64) vg.Length {
+		return c.Min.Y + vg.Length((v-cc.Lo)/(cc.Hi-cc.Lo))*(c.Max.Y-c.Min.Y)
+	}
+	pts := make([]vg.Point, len(cc.XYs))
+	for i, xy := range cc.XYs {
+		pts[i] = vg.Point{X: trX(xy.X), Y: trY(xy.Y)}
+	}
+	c.StrokeLines(cc.LineStyle, c.ClipLinesXY(pts)...)
+
+	for _, v := range []float64{cc.Hi, cc.Lo} {
+		c.FillText(cc.TextStyle, vg.Point{X: c.Max.X - vg.Points(2), Y: trY(v)}, fmt.Sprintf("Σ %.0f", v))
+	}
+}
+
+// Thumbnail implements the plot.Thumbnailer interface.
+func (cc *cumulativeCurve) Thumbnail(c *draw.Canvas) {
+	y := c.Center().Y
+	c.StrokeLine2(cc.LineStyle, c.Min.X, y, c.Max.X, y)
+}
@@ -0,0 +1,70 @@
+package render
+
+import (
+	"image/color"
+	"math"
+	"strconv"
+
+	"gonum.org/v1/plot"
+	"gonum.org/v1/plot/font"
+	"gonum.org/v1/plot/vg"
+	"gonum.org/v1/plot/vg/draw"
+
+	"github.com/rojaswestall/lifeline/internal/adjust"
+)
+
+// decadeTicks draws short marks on the zero axis line at decade boundaries,
+// optionally captioned with the decade year. It gives long timelines a
+// temporal anchor without turning on the full year axis.
+type decadeTicks struct {
+	Years   []float64 // decade years, e.g. 1990, 2000
+	Xs      []float64 // plotted x position of each decade
+	Caption bool
+
+	LineStyle draw.LineStyle
+	TextStyle draw.TextStyle
+	Length    vg.Length // half-length of each tick mark
+}
+
+// newDecadeTicks returns ticks for every decade between the first and last
+// original years that falls inside [xMin, xMax] once mapped through m.
+func newDecadeTicks(m adjust.YearMap, firstYear, lastYear, xMin, xMax float64, caption bool) *decadeTicks {
+	grey := color.RGBA{A: 255, R: 190, G: 190, B: 190}
+	d := &decadeTicks{
+		Caption: caption,
+		LineStyle: draw.LineStyle{
+			Color: grey,
+			Width: vg.Points(0.75),
+		},
+		TextStyle: draw.TextStyle{
+			Color:   color.RGBA{A: 255, R: 160, G: 160, B: 160},
+			Font:    font.From(plot.DefaultFont, vg.Points(7)),
+			XAlign:  draw.XCenter,
+			YAlign:  draw.YTop,
+			Handler: plot.DefaultTextHandler,
+		},
+		Length: vg.Points(3),
+	}
+	for y := math.Ceil(firstYear/10) * 10; y <= lastYear; y += 10 {
+		x := m.X(y)
+		if x < xMin || x > xMax {
+			continue
+		}
+		d.Years = append(d.Years, y)
+		d.Xs = append(d.Xs, x)
+	}
+	return d
+}
+
+// Plot implements the plot.Plotter interface.
+func (d *decadeTicks) Plot(c draw.Canvas, plt *plot.Plot) {
+	trX, trY := plt.Transforms(&c)
+	y0 := trY(0)
+	for i, x := range d.Xs {
+		cx := trX(x)
+		c.StrokeLine2(d.LineStyle, cx, y0-d.Length, cx, y0+d.Length)
+		if d.Caption {
+			c.FillText(d.TextStyle, vg.Point{X: cx, Y: y0 - d.Length - vg.Points(1)}, strconv.Itoa(int(d.Years[i])))
+		}
+	}
+}
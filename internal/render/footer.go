@@ -0,0 +1,30 @@
+package render
+
+import (
+	"gonum.org/v1/plot/text"
+	"gonum.org/v1/plot/vg"
+	"gonum.org/v1/plot/vg/draw"
+)
+
+// footerPad is the space above and below the -footer line.
+const footerPad = 4 // points
+
+// Footer is a line of text centered under the chart, set with
+// -footer.
+type Footer struct {
+	Text  string
+	Style text.Style
+}
+
+// drawFooter draws f at the bottom of c and returns the part of c above
+// it, where the plot goes. A nil or empty footer leaves c to the plot.
+func drawFooter(c draw.Canvas, f *Footer) draw.Canvas {
+	if f == nil || f.Text == "" {
+		return c
+	}
+	sty := f.Style
+	sty.XAlign, sty.YAlign = text.XCenter, text.YBottom
+	pad := vg.Points(footerPad)
+	c.FillText(sty, vg.Point{X: c.Center().X, Y: c.Min.Y + pad}, f.Text)
+	return draw.Crop(c, 0, 0, sty.Height(f.Text)+2*pad, 0)
+}
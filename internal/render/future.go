@@ -0,0 +1,40 @@
+package render
+
+import (
+	"image/color"
+
+	"gonum.org/v1/plot/plotter"
+	"gonum.org/v1/plot/vg"
+)
+
+// futureDashes is the dash pattern of the line beyond -now.
+var futureDashes = []vg.Length{vg.Points(4), vg.Points(3)}
+
+// splitAt cuts a line at x. Both halves include the interpolated point at
+// x, so drawn one after the other they join without a gap. Points exactly
+// at x belong to the past.
+func splitAt(xys plotter.XYs, x float64) (past, future plotter.XYs) {
+	for i, pt := range xys {
+		if pt.X <= x {
+			past = append(past, pt)
+			continue
+		}
+		if i > 0 {
+			prev := xys[i-1]
+			t := (x - prev.X) / (pt.X - prev.X)
+			mid := plotter.XY{X: x, Y: prev.Y + t*(pt.Y-prev.Y)}
+			past = append(past, mid)
+			future = append(future, mid)
+		}
+		future = append(future, xys[i:]...)
+		break
+	}
+	return past, future
+}
+
+// faded returns c at 40% of its opacity, for planned events.
+func faded(c color.Color) color.NRGBA {
+	n := color.NRGBAModel.Convert(c).(color.NRGBA)
+	n.A = uint8(int(n.A) * 2 / 5)
+	return n
+}
@@ -0,0 +1,111 @@
+package render
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+
+	xfont "golang.org/x/image/font"
+	"gonum.org/v1/plot"
+	"gonum.org/v1/plot/font"
+	"gonum.org/v1/plot/plotter"
+	"gonum.org/v1/plot/vg"
+	"gonum.org/v1/plot/vg/draw"
+
+	"github.com/rojaswestall/lifeline/internal/adjust"
+)
+
+// gapDashes is the dash pattern of segments spanning a -gap-years gap.
+var gapDashes = []vg.Length{vg.Points(1), vg.Points(3)}
+
+// lineRun is a stretch of the connecting line whose segments are either
+// all ordinary or all gaps.
+type lineRun struct {
+	XYs plotter.XYs
+	Gap bool
+}
+
+// splitGaps cuts the line into runs, marking as gaps the segments between
+// consecutive events more than gapYears apart. years holds the original
+// year of each point; the plotted x positions are density adjusted and
+// shrink exactly these gaps, so they cannot be used for detection.
+func splitGaps(xys plotter.XYs, years []float64, gapYears float64) []lineRun {
+	var runs []lineRun
+	for i := 1; i < len(xys); i++ {
+		gap := years[i]-years[i-1] > gapYears
+		if n := len(runs); n > 0 && runs[n-1].Gap == gap {
+			runs[n-1].XYs = append(runs[n-1].XYs, xys[i])
+			continue
+		}
+		runs = append(runs, lineRun{XYs: plotter.XYs{xys[i-1], xys[i]}, Gap: gap})
+	}
+	return runs
+}
+
+// gapMark draws a small ellipsis where a broken gap segment would have had
+// its midpoint.
+type gapMark struct {
+	X, Y  float64
+	Color color.Color
+}
+
+// Plot implements the plot.Plotter interface.
+func (g gapMark) Plot(c draw.Canvas, plt *plot.Plot) {
+	trX, trY := plt.Transforms(&c)
+	center := vg.Point{X: trX(g.X), Y: trY(g.Y)}
+	dot := draw.GlyphStyle{Color: g.Color, Radius: vg.Points(1.2), Shape: draw.CircleGlyph{}}
+	for _, dx := range []vg.Length{-4, 0, 4} {
+		c.DrawGlyph(dot, center.Add(vg.Point{X: vg.Points(float64(dx))}))
+	}
+}
+
+// quietGap is a stretch of at least one whole year without events.
+type quietGap struct {
+	X     float64 // plotted x of the middle of the gap
+	Years int     // whole years between the two events
+}
+
+// findQuietGaps returns the gaps between consecutive original years longer
+// than minYears, positioned in plotted coordinates through m.
+func findQuietGaps(years []float64, m adjust.YearMap, minYears float64) []quietGap {
+	var gaps []quietGap
+	for i := 1; i < len(years); i++ {
+		d := years[i] - years[i-1]
+		if d <= minYears {
+			continue
+		}
+		gaps = append(gaps, quietGap{X: m.X((years[i] + years[i-1]) / 2), Years: int(math.Round(d)) - 1})
+	}
+	return gaps
+}
+
+// quietLabels captions quiet gaps just above the zero axis.
+type quietLabels struct {
+	Gaps      []quietGap
+	TextStyle draw.TextStyle
+}
+
+func newQuietLabels(gaps []quietGap) *quietLabels {
+	sty := draw.TextStyle{
+		Color:   color.Gray{Y: 150},
+		Font:    font.From(plot.DefaultFont, vg.Points(7)),
+		XAlign:  draw.XCenter,
+		YAlign:  draw.YBottom,
+		Handler: plot.DefaultTextHandler,
+	}
+	sty.Font.Style = xfont.StyleItalic
+	return &quietLabels{Gaps: gaps, TextStyle: sty}
+}
+
+// Plot implements the plot.Plotter interface.
+func (q *quietLabels) Plot(c draw.Canvas, plt *plot.Plot) {
+	trX, trY := plt.Transforms(&c)
+	for _, g := range q.Gaps {
+		unit := "years"
+		if g.Years == 1 {
+			unit = "year"
+		}
+		txt := fmt.Sprintf("· %d quiet %s ·", g.Years, unit)
+		c.FillText(q.TextStyle, vg.Point{X: trX(g.X), Y: trY(0) + vg.Points(2)}, txt)
+	}
+}
@@ -0,0 +1,52 @@
+package render
+
+import (
+	"image"
+	"image/color"
+
+	"gonum.org/v1/plot/vg"
+)
+
+// grayCanvas converts every color and image drawn through it to grey, so
+// the whole render (whatever palette or theme produced it) prints well on
+// a black-and-white printer.
+type grayCanvas struct {
+	vg.CanvasSizer
+}
+
+func (c grayCanvas) SetColor(col color.Color) {
+	c.CanvasSizer.SetColor(toGray(col))
+}
+
+func (c grayCanvas) DrawImage(rect vg.Rectangle, img image.Image) {
+	b := img.Bounds()
+	out := image.NewNRGBA(b)
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			out.Set(x, y, toGray(img.At(x, y)))
+		}
+	}
+	c.CanvasSizer.DrawImage(rect, out)
+}
+
+// toGray returns col as a grey of the same perceived lightness (Rec. 601
+// luma weights), keeping its opacity.
+func toGray(col color.Color) color.Color {
+	if col == nil {
+		return nil
+	}
+	n := color.NRGBAModel.Convert(col).(color.NRGBA)
+	y := uint8((299*int(n.R) + 587*int(n.G) + 114*int(n.B) + 500) / 1000)
+	return color.NRGBA{R: y, G: y, B: y, A: n.A}
+}
+
+// grayscalePalette darkens the line and markers of pal so they keep their
+// contrast once converted to grey.
+func grayscalePalette(pal palette) palette {
+	pal.Line = color.RGBA{A: 255, R: 60, G: 60, B: 60}
+	pal.Marker = color.RGBA{A: 255}
+	pal.Highlight = color.RGBA{A: 255}
+	pal.Positive = color.RGBA{A: 255, R: 170, G: 170, B: 170}
+	pal.Negative = color.RGBA{A: 255, R: 70, G: 70, B: 70}
+	return pal
+}
@@ -0,0 +1,52 @@
+package render
+
+import (
+	"path/filepath"
+	"strings"
+
+	"gonum.org/v1/plot"
+	"gonum.org/v1/plot/vg"
+	"gonum.org/v1/plot/vg/draw"
+)
+
+// Panel is one chart of a `lifeline grid` montage, drawn but not yet
+// placed.
+type Panel struct {
+	Plot   *plot.Plot
+	Footer *Footer
+	W, H   vg.Length // the size the chart was laid out for
+}
+
+// DrawGrid draws panels row by row, cols to a row, onto one canvas in the
+// format of file's extension. Every cell is the size of the largest
+// panel, so the canvas grows with the grid.
+func DrawGrid(panels []Panel, cols int, margin vg.Length, gray bool, file string) (vg.CanvasWriterTo, error) {
+	var cw, ch vg.Length
+	for _, p := range panels {
+		cw, ch = max(cw, p.W), max(ch, p.H)
+	}
+	rows := (len(panels) + cols - 1) / cols
+	format := strings.TrimPrefix(strings.ToLower(filepath.Ext(file)), ".")
+	c, err := draw.NewFormattedCanvas(cw*vg.Length(cols), ch*vg.Length(rows), format)
+	if err != nil {
+		return nil, err
+	}
+	var vc vg.CanvasSizer = c
+	if gray {
+		vc = grayCanvas{c}
+	}
+	dc := draw.New(vc)
+	tiles := draw.Tiles{Rows: rows, Cols: cols}
+	for i, p := range panels {
+		cell := tiles.At(dc, i%cols, i/cols)
+		if margin > 0 {
+			if p.Plot.BackgroundColor != nil {
+				cell.SetColor(p.Plot.BackgroundColor)
+				cell.Fill(cell.Rectangle.Path())
+			}
+			cell = draw.Crop(cell, margin, -margin, margin, -margin)
+		}
+		p.Plot.Draw(drawFooter(cell, p.Footer))
+	}
+	return c, nil
+}
@@ -0,0 +1,23 @@
+package render
+
+import (
+	"image/color"
+
+	xfont "golang.org/x/image/font"
+	"gonum.org/v1/plot/vg"
+	"gonum.org/v1/plot/vg/draw"
+)
+
+// emphasize returns sty in bold and 20% larger, for highlighted labels.
+func emphasize(sty draw.TextStyle) draw.TextStyle {
+	sty.Font.Weight = xfont.WeightBold
+	sty.Font.Size = sty.Font.Size * 6 / 5
+	return sty
+}
+
+// emphasizeGlyph returns sty with a larger marker in the highlight color c.
+func emphasizeGlyph(sty draw.GlyphStyle, c color.Color) draw.GlyphStyle {
+	sty.Color = c
+	sty.Radius = max(sty.Radius*3/2, vg.Points(4.5))
+	return sty
+}
@@ -0,0 +1,93 @@
+package render
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+
+	"github.com/rojaswestall/lifeline/internal/exit"
+	"github.com/rojaswestall/lifeline/internal/logging"
+	"github.com/rojaswestall/lifeline/internal/timeline"
+)
+
+// normalizationFor returns the -normalize mapping for the input file: the
+// one naming it with file=, or else the one without, if any.
+func (o *Options) normalizationFor(file string) (timeline.Normalization, bool) {
+	var all *timeline.Normalization
+	for i, n := range o.normalize {
+		if n.File == "" {
+			all = &o.normalize[i]
+		} else if timeline.SameFile(n.File, file) {
+			return n, true
+		}
+	}
+	if all == nil {
+		return timeline.Normalization{}, false
+	}
+	return *all, true
+}
+
+// normalizeValues maps the values of pts, read from file, as -normalize
+// says, and reports whether it did.
+func (o *Options) normalizeValues(file string, pts []timeline.Point) (bool, error) {
+	norm, ok := o.normalizationFor(file)
+	if !ok {
+		return false, nil
+	}
+	if err := norm.Apply(pts); err != nil {
+		return false, exit.InputError(fmt.Errorf("-normalize %s: %w", file, err))
+	}
+	logging.Notef("Normalized values of %s %s\n", filepath.Base(file), norm)
+	return true, nil
+}
+
+// invertValues negates the values of pts, read from file, when
+// -invert-values or an -invert naming file says so, and reports whether
+// it did.
+func (o *Options) invertValues(file string, pts []timeline.Point) bool {
+	if !o.InvertValues && !slices.ContainsFunc(o.Invert, func(name string) bool { return timeline.SameFile(name, file) }) {
+		return false
+	}
+	for i := range pts {
+		pts[i].Value = -pts[i].Value
+	}
+	logging.Notef("Inverted values of %s\n", filepath.Base(file))
+	return true
+}
+
+// CheckInputs reports an error for a per-file setting that names none of
+// inputs or the -band files, which would otherwise be silently ignored.
+func (o *Options) CheckInputs(inputs []string) error {
+	if o.Band != "" {
+		// A bad -band is reported when the chart is drawn.
+		files, _ := timeline.BandFiles(o.Band)
+		inputs = append(inputs[:len(inputs):len(inputs)], files...)
+	}
+	named := func(name string) bool {
+		for _, in := range inputs {
+			if timeline.SameFile(name, in) {
+				return true
+			}
+		}
+		return false
+	}
+	for _, n := range o.normalize {
+		if n.File != "" && !named(n.File) {
+			return fmt.Errorf("invalid -normalize: file=%s names none of the input files", n.File)
+		}
+	}
+	for _, name := range o.Invert {
+		if !named(name) {
+			return fmt.Errorf("invalid -invert %s: names none of the input files", name)
+		}
+	}
+	return nil
+}
+
+// describeFile names the inputs a per-file setting applies to in errors.
+func describeFile(name string) string {
+	if name == "" {
+		return "every input"
+	}
+	return name
+}
@@ -0,0 +1,64 @@
+package render
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/rojaswestall/lifeline/internal/timeline"
+)
+
+func TestNormalizationFor(t *testing.T) {
+	o := &Options{}
+	for _, s := range []string{"from=0:100", "from=0:5 to=0:10 file=old.csv", "from=1:7 file=data/band/b.csv"} {
+		n, err := timeline.ParseNormalization(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		o.normalize = append(o.normalize, n)
+	}
+	for _, tc := range []struct {
+		file   string
+		fromHi float64
+	}{
+		{"new.csv", 100},
+		{"old.csv", 5},
+		{"data/old.csv", 5},
+		{"data/band/b.csv", 7},
+		{"b.csv", 100},
+	} {
+		n, ok := o.normalizationFor(tc.file)
+		if !ok || n.FromHi != tc.fromHi {
+			t.Errorf("%s: got from %g:%g (%v), want 0:%g", tc.file, n.FromLo, n.FromHi, ok, tc.fromHi)
+		}
+	}
+
+	one := &Options{normalize: o.normalize[1:]}
+	if _, ok := one.normalizationFor("new.csv"); ok {
+		t.Errorf("new.csv is normalized with only a mapping for old.csv")
+	}
+	if err := one.CheckInputs([]string{"data/old.csv", "data/band/b.csv"}); err != nil {
+		t.Errorf("CheckInputs: %v", err)
+	}
+	if err := one.CheckInputs([]string{"new.csv"}); err == nil || !strings.Contains(err.Error(), "file=old.csv") {
+		t.Errorf("CheckInputs without old.csv = %v, want an error naming it", err)
+	}
+}
+
+func TestInvertValues(t *testing.T) {
+	o := &Options{Invert: []string{"stress.csv"}}
+	for _, tc := range []struct {
+		file string
+		want float64
+	}{
+		{"data/stress.csv", -4},
+		{"mood.csv", 4},
+	} {
+		pts := []timeline.Point{{Year: 2001, Value: 4}}
+		if inverted := o.invertValues(tc.file, pts); inverted != (tc.want < 0) || pts[0].Value != tc.want {
+			t.Errorf("%s: got %g (inverted %v), want %g", tc.file, pts[0].Value, inverted, tc.want)
+		}
+	}
+	if err := o.CheckInputs([]string{"mood.csv"}); err == nil || !strings.Contains(err.Error(), "-invert stress.csv") {
+		t.Errorf("CheckInputs without stress.csv = %v, want an error naming it", err)
+	}
+}
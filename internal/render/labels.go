@@ -0,0 +1,287 @@
+package render
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"gonum.org/v1/plot"
+	"gonum.org/v1/plot/vg"
+	"gonum.org/v1/plot/vg/draw"
+
+	"github.com/rojaswestall/lifeline/internal/timeline"
+)
+
+// labelRule decides which points get a caption. Points that are not
+// labeled are still drawn with a marker.
+type labelRule struct {
+	kind      string  // "all", "every", "abs" or "tagged"
+	every     int     // for "every": label every Nth point
+	threshold float64 // for "abs": minimum |value| to label
+}
+
+// parseLabelRule parses the -label-rule flag value:
+// all, every=N, abs>=X or tagged.
+func parseLabelRule(s string) (labelRule, error) {
+	s = strings.TrimSpace(s)
+	switch {
+	case s == "" || s == "all":
+		return labelRule{kind: "all"}, nil
+	case s == "tagged":
+		return labelRule{kind: "tagged"}, nil
+	case strings.HasPrefix(s, "every="):
+		n, err := strconv.Atoi(strings.TrimPrefix(s, "every="))
+		if err != nil || n < 1 {
+			return labelRule{}, fmt.Errorf("%q: N must be a positive integer", s)
+		}
+		return labelRule{kind: "every", every: n}, nil
+	case strings.HasPrefix(s, "abs>="):
+		x, err := strconv.ParseFloat(strings.TrimPrefix(s, "abs>="), 64)
+		if err != nil {
+			return labelRule{}, fmt.Errorf("%q: X must be a number", s)
+		}
+		return labelRule{kind: "abs", threshold: x}, nil
+	}
+	return labelRule{}, fmt.Errorf("%q: expected all, every=N, abs>=X or tagged", s)
+}
+
+// keep reports whether the i-th point (in chronological order) gets a label.
+// Highlighted points are always labeled; interpolated and zero-filled ones
+// never are.
+func (r labelRule) keep(i int, p timeline.Point) bool {
+	if p.Interpolated || p.ZeroFilled {
+		return false
+	}
+	if p.Highlight {
+		return true
+	}
+	switch r.kind {
+	case "every":
+		return i%r.every == 0
+	case "abs":
+		return math.Abs(p.Value) >= r.threshold
+	case "tagged":
+		return p.Tagged
+	}
+	return true
+}
+
+// plotView estimates where data coordinates land on the canvas before the
+// plot is drawn, so label placement can react to the plot edges.
+type plotView struct {
+	xMin, xMax, yMin, yMax float64
+	size                   vg.Point // estimated data area in canvas units
+}
+
+func newPlotView(p *plot.Plot, w, h vg.Length) plotView {
+	// Leave room for the title and a little axis padding.
+	titleH := vg.Length(0)
+	if p.Title.Text != "" {
+		titleH = p.Title.TextStyle.Height(p.Title.Text) + p.Title.Padding
+	}
+	return plotView{
+		xMin: p.X.Min, xMax: p.X.Max, yMin: p.Y.Min, yMax: p.Y.Max,
+		size: vg.Point{X: w - vg.Points(20), Y: h - titleH - vg.Points(20)},
+	}
+}
+
+// at returns the estimated canvas position of a data point.
+func (v plotView) at(x, y float64) vg.Point {
+	return vg.Point{
+		X: v.size.X * vg.Length((x-v.xMin)/(v.xMax-v.xMin)),
+		Y: v.size.Y * vg.Length((y-v.yMin)/(v.yMax-v.yMin)),
+	}
+}
+
+// flipIntoView mirrors a label's offset horizontally and/or vertically
+// when the label, drawn with sty at anchor+offset, would extend past the
+// data area. It only flips a direction when the mirrored placement fits,
+// so the result is deterministic and never worse than the original. Boxes
+// are measured as orientLabel draws the label at each offset, so rotated
+// labels are checked by their rotated extent.
+func (v plotView) flipIntoView(anchor, offset vg.Point, sty draw.TextStyle, label string, angle float64) vg.Point {
+	fits := func(off vg.Point) (x, y bool) {
+		r := orientLabel(sty, off, angle).Rectangle(label).Add(anchor.Add(off))
+		return r.Min.X >= 0 && r.Max.X <= v.size.X, r.Min.Y >= 0 && r.Max.Y <= v.size.Y
+	}
+	okX, okY := fits(offset)
+	if !okX {
+		if mx, _ := fits(vg.Point{X: -offset.X, Y: offset.Y}); mx {
+			offset.X = -offset.X
+		}
+	}
+	if !okY {
+		if _, my := fits(vg.Point{X: offset.X, Y: -offset.Y}); my {
+			offset.Y = -offset.Y
+		}
+	}
+	return offset
+}
+
+// anchoredBox is a caption's estimated box relative to its point, with
+// the point in data coordinates.
+type anchoredBox struct {
+	X, Y float64
+	Rel  vg.Rectangle
+}
+
+// widenToFit grows the data ranges of v until every box lies inside the
+// data area, for captions that still run off the plot after flipIntoView.
+// Only the axes with fitX or fitY set may grow, each side by the least
+// amount that fits its boxes; a box too large for the whole area is left
+// to overflow. It reports whether any range changed.
+func (v *plotView) widenToFit(boxes []anchoredBox, fitX, fitY bool) bool {
+	before := *v
+	// Growing one side moves every point towards it, which can push
+	// captions off the other side, so repeat until the ranges settle.
+	for range 8 {
+		prev := *v
+		for _, b := range boxes {
+			if fitX {
+				v.xMin, v.xMax = widenAxis(v.xMin, v.xMax, v.size.X, b.X, b.Rel.Min.X, b.Rel.Max.X)
+			}
+			if fitY {
+				v.yMin, v.yMax = widenAxis(v.yMin, v.yMax, v.size.Y, b.Y, b.Rel.Min.Y, b.Rel.Max.Y)
+			}
+		}
+		if *v == prev {
+			break
+		}
+	}
+	return *v != before
+}
+
+// widenAxis returns the range [lo, hi], drawn over size canvas units,
+// grown so that the extent from lo to hi around pos, given in canvas
+// units relative to pos, fits inside it.
+func widenAxis(lo, hi float64, size vg.Length, pos float64, from, to vg.Length) (float64, float64) {
+	s, below, above := float64(size), -float64(from), float64(to)
+	if below+above >= s || hi <= lo {
+		return lo, hi
+	}
+	if s*(pos-lo)/(hi-lo)+above > s {
+		hi = lo + s*(pos-lo)/(s-above)
+	}
+	if s*(pos-lo)/(hi-lo) < below {
+		lo = (s*pos - below*hi) / (s - below)
+	}
+	return lo, hi
+}
+
+// sideAlign anchors a label by the edge nearest its point: labels placed
+// to the left end at the offset, so long or wide-script text grows away
+// from the marker instead of across it.
+func sideAlign(offset vg.Point) draw.XAlignment {
+	if offset.X < 0 {
+		return draw.XRight
+	}
+	return draw.XLeft
+}
+
+// orientLabel returns sty as a label at offset from its point is drawn:
+// anchored by sideAlign and, for a nonzero angle in degrees, pivoting on
+// the end of the text nearest the marker and tilted away from the point,
+// up for top quadrants and down for bottom ones.
+func orientLabel(sty draw.TextStyle, offset vg.Point, angle float64) draw.TextStyle {
+	sty.XAlign = sideAlign(offset)
+	if angle != 0 {
+		theta := angle * math.Pi / 180
+		if (offset.Y > 0) == (offset.X < 0) {
+			theta = -theta
+		}
+		sty.YAlign = draw.YCenter
+		sty.Rotation = theta
+	}
+	return sty
+}
+
+// clampBox shifts a box centered at x with the given width so it stays
+// within [lo, hi] where possible.
+func clampBox(x, width, lo, hi vg.Length) vg.Length {
+	if x+width/2 > hi {
+		x = hi - width/2
+	}
+	if x-width/2 < lo {
+		x = lo + width/2
+	}
+	return x
+}
+
+// labelQuadrants are the label directions by slot: top-right, bottom-right,
+// top-left and bottom-left.
+var labelQuadrants = [4]vg.Point{{X: 1, Y: 1}, {X: 1, Y: -1}, {X: -1, Y: 1}, {X: -1, Y: -1}}
+
+// pickQuadrant chooses the slot whose direction points furthest away from
+// the line segments entering and leaving pt, which puts the label on the
+// convex side of the path. neighbors are the canvas positions of the
+// previous and next points, if any. Slots that score within a small
+// tolerance of the best count as tied, and ties go to fallback when it is
+// among them, otherwise to the lowest slot.
+func pickQuadrant(pt vg.Point, neighbors []vg.Point, fallback int) int {
+	if len(neighbors) == 0 {
+		return fallback
+	}
+	var scores [4]float64
+	best := math.Inf(-1)
+	for q, d := range labelQuadrants {
+		worst := -1.0
+		for _, n := range neighbors {
+			u := n.Sub(pt)
+			l := math.Hypot(float64(u.X), float64(u.Y))
+			if l == 0 {
+				continue
+			}
+			cos := (float64(u.X)*float64(d.X) + float64(u.Y)*float64(d.Y)) / (l * math.Sqrt2)
+			worst = math.Max(worst, cos)
+		}
+		scores[q] = -worst
+		best = math.Max(best, scores[q])
+	}
+	const tolerance = 0.05
+	if scores[fallback] >= best-tolerance {
+		return fallback
+	}
+	for q, s := range scores {
+		if s >= best-tolerance {
+			return q
+		}
+	}
+	return fallback
+}
+
+// defaultLabelData is what -default-label-format templates can refer to.
+type defaultLabelData struct {
+	Year  float64
+	Value float64
+	Date  string // the year as written, e.g. "2014" or "2010-2014"
+	Sign  string // "+", "-" or "" by polarity
+}
+
+// parseDefaultLabel compiles a -default-label-format template and runs it
+// once on sample data, so mistakes surface at startup rather than per row.
+func parseDefaultLabel(s string) (*template.Template, error) {
+	t, err := template.New("label").Parse(s)
+	if err != nil {
+		return nil, err
+	}
+	if err := t.Execute(io.Discard, defaultLabelData{Year: 2000, Value: 1, Date: "2000", Sign: "+"}); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// defaultLabel renders t for a point that had no label in the CSV.
+func defaultLabel(t *template.Template, p timeline.Point) string {
+	d := defaultLabelData{Year: p.Year, Value: p.Value, Date: strconv.FormatFloat(p.Year, 'f', -1, 64), Sign: p.Polarity().Sign()}
+	if p.End != 0 {
+		d.Date += "-" + strconv.FormatFloat(p.End, 'f', -1, 64)
+	}
+	var b strings.Builder
+	if err := t.Execute(&b, d); err != nil {
+		return timeline.AutoLabel(p.Year, p.Value, false)
+	}
+	return b.String()
+}
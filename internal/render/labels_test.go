@@ -0,0 +1,121 @@
+package render
+
+import (
+	"testing"
+
+	"gonum.org/v1/plot"
+	"gonum.org/v1/plot/font"
+	"gonum.org/v1/plot/vg"
+	"gonum.org/v1/plot/vg/draw"
+)
+
+// labelStyle is the text style captions start from.
+func labelStyle() draw.TextStyle {
+	return draw.TextStyle{
+		Font:    font.From(plot.DefaultFont, vg.Points(9)),
+		Handler: plot.DefaultTextHandler,
+	}
+}
+
+func TestFlipIntoViewRotated(t *testing.T) {
+	v := plotView{xMin: 0, xMax: 1, yMin: 0, yMax: 1, size: vg.Point{X: 400, Y: 300}}
+	const label = "A long caption that tilts upwards"
+	anchor := vg.Point{X: 100, Y: 280}
+	off := vg.Point{X: 8, Y: 8}
+
+	// Level, the caption is short enough to fit above the point; tilted
+	// by 30°, its far end would leave the top of the plot.
+	if got := v.flipIntoView(anchor, off, labelStyle(), label, 0); got != off {
+		t.Errorf("level label moved to %v, want %v", got, off)
+	}
+	got := v.flipIntoView(anchor, off, labelStyle(), label, 30)
+	if got.Y >= 0 {
+		t.Fatalf("tilted label stays above the point at %v", got)
+	}
+	r := orientLabel(labelStyle(), got, 30).Rectangle(label).Add(anchor.Add(got))
+	if r.Max.Y > v.size.Y || r.Min.Y < 0 {
+		t.Errorf("tilted label spans y %v to %v, outside 0 to %v", r.Min.Y, r.Max.Y, v.size.Y)
+	}
+}
+
+// placeCaption lays out a caption for the point (x, y) as Draw does:
+// flipped away from the edges, then with the axes widened if it still
+// doesn't fit. It returns the caption's final box.
+func placeCaption(v *plotView, x, y float64, off vg.Point, label string) vg.Rectangle {
+	off = v.flipIntoView(v.at(x, y), off, labelStyle(), label, 0)
+	rel := orientLabel(labelStyle(), off, 0).Rectangle(label).Add(off)
+	v.widenToFit([]anchoredBox{{X: x, Y: y, Rel: rel}}, true, true)
+	return rel.Add(v.at(x, y))
+}
+
+// inView reports whether r lies inside the data area of v, allowing for
+// rounding.
+func inView(v plotView, r vg.Rectangle) bool {
+	const eps = 1e-6
+	return r.Min.X >= -eps && r.Min.Y >= -eps && r.Max.X <= v.size.X+eps && r.Max.Y <= v.size.Y+eps
+}
+
+func TestLastPointLabelInView(t *testing.T) {
+	// The reported case: the last event at the maximum value, its caption
+	// starting out to the top right, past both edges.
+	v := plotView{xMin: 1990, xMax: 2020, yMin: -10.5, yMax: 10.5, size: vg.Point{X: 800, Y: 500}}
+	const label = "Wins the championship"
+	r := placeCaption(&v, 2020, 10, vg.Point{X: 8, Y: 8}, label)
+	if !inView(v, r) {
+		t.Errorf("caption box %v runs outside the plot %v", r, v.size)
+	}
+	if v.xMin != 1990 || v.xMax != 2020 || v.yMin != -10.5 || v.yMax != 10.5 {
+		t.Errorf("flipping should have been enough, but the axes changed to %v", v)
+	}
+}
+
+func TestWidenToFit(t *testing.T) {
+	const label = "Moves abroad for a new job"
+	wide := labelStyle().Width(label)
+	// The point sits mid-plot with a caption too wide for either side.
+	v := plotView{xMin: 2000, xMax: 2010, yMin: -10, yMax: 10, size: vg.Point{X: 1.5 * wide, Y: 300}}
+	r := placeCaption(&v, 2005, 10, vg.Point{X: 8, Y: 8}, label)
+	if !inView(v, r) {
+		t.Errorf("caption box %v runs outside the plot %v", r, v.size)
+	}
+	if v.xMin > 2000 || v.xMax < 2010 || v.yMin > -10 || v.yMax < 10 {
+		t.Errorf("ranges shrank to %v", v)
+	}
+	if v.xMin == 2000 && v.xMax == 2010 {
+		t.Errorf("x range not widened")
+	}
+
+	// Fixed ranges stay as they are.
+	fixed := plotView{xMin: 2000, xMax: 2010, yMin: -10, yMax: 10, size: v.size}
+	rel := labelStyle().Rectangle(label).Add(vg.Point{X: 8, Y: 8})
+	if fixed.widenToFit([]anchoredBox{{X: 2005, Y: 10, Rel: rel}}, false, false) {
+		t.Errorf("fixed ranges changed to %v", fixed)
+	}
+}
+
+func TestWideScriptLabelsInView(t *testing.T) {
+	v := plotView{xMin: 0, xMax: 1, yMin: 0, yMax: 1, size: vg.Point{X: 600, Y: 400}}
+	labels := []string{
+		"東京で新しい仕事を始める",
+		"대학교 졸업식",
+		"🎉🎂 Birthday party 🎈",
+		"Moved to 上海 🚀✨",
+	}
+	anchors := []vg.Point{{X: 4, Y: 200}, {X: 596, Y: 200}, {X: 300, Y: 396}, {X: 596, Y: 4}, {X: 4, Y: 396}}
+	for _, label := range labels {
+		for _, at := range anchors {
+			for _, q := range labelQuadrants {
+				off := v.flipIntoView(at, q.Scale(8), labelStyle(), label, 0)
+				r := orientLabel(labelStyle(), off, 0).Rectangle(label).Add(at.Add(off))
+				if !inView(v, r) {
+					t.Errorf("%q at %v: box %v runs outside the plot %v", label, at, r, v.size)
+				}
+				// Left placements end left of the point, right ones start
+				// right of it, however wide the text.
+				if off.X < 0 && r.Max.X > at.X || off.X > 0 && r.Min.X < at.X {
+					t.Errorf("%q at %v, offset %v: box %v covers the point", label, at, off, r)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,135 @@
+package render
+
+import (
+	"image/color"
+
+	"gonum.org/v1/plot"
+	"gonum.org/v1/plot/font"
+	"gonum.org/v1/plot/vg"
+	"gonum.org/v1/plot/vg/draw"
+)
+
+// laneGap is the minimum horizontal space between two labels sharing a lane.
+const laneGap = 6 // points
+
+// assignLanes puts each label, given by its center and width in canvas
+// units and sorted by center, into the first lane where it doesn't overlap
+// the previous label. It returns the lane of each label and the number of
+// lanes used.
+func assignLanes(centers, widths []vg.Length) (lanes []int, n int) {
+	var ends []vg.Length
+	lanes = make([]int, len(centers))
+	for i, c := range centers {
+		start := c - widths[i]/2
+		lane := -1
+		for l, end := range ends {
+			if end+vg.Points(laneGap) <= start {
+				lane = l
+				break
+			}
+		}
+		if lane < 0 {
+			ends = append(ends, 0)
+			lane = len(ends) - 1
+		}
+		ends[lane] = c + widths[i]/2
+		lanes[i] = lane
+	}
+	return lanes, len(ends)
+}
+
+// laneLabel is a label placed in the -label-lanes bands.
+type laneLabel struct {
+	X, Y  float64 // data coordinates of the point it describes
+	Label string
+	Top   bool // in the band above the plot rather than below
+}
+
+// labelLanes draws labels in horizontal lanes reserved at the top and
+// bottom of the data area, each connected to its point by a thin leader.
+// Labels in the same lane never overlap horizontally.
+type labelLanes struct {
+	Labels     []laneLabel
+	LaneHeight vg.Length
+
+	TextStyle draw.TextStyle
+	LineStyle draw.LineStyle
+}
+
+func newLabelLanes(labels []laneLabel, size vg.Length) *labelLanes {
+	sty := draw.TextStyle{
+		Color:   color.Black,
+		Font:    font.From(plot.DefaultFont, size),
+		XAlign:  draw.XCenter,
+		YAlign:  draw.YCenter,
+		Handler: plot.DefaultTextHandler,
+	}
+	return &labelLanes{
+		Labels:     labels,
+		LaneHeight: sty.Height("Xg") * 1.6,
+		TextStyle:  sty,
+		LineStyle:  draw.LineStyle{Color: color.Gray{Y: 180}, Width: vg.Points(0.5)},
+	}
+}
+
+// layout assigns lanes for one band using the given x transform and
+// returns each label's lane (indexed like l.Labels; -1 for the other band)
+// and the number of lanes used in that band.
+func (l *labelLanes) layout(trX func(float64) vg.Length, top bool) ([]int, int) {
+	var idx []int
+	var centers, widths []vg.Length
+	for i, lb := range l.Labels {
+		if lb.Top != top {
+			continue
+		}
+		idx = append(idx, i)
+		centers = append(centers, trX(lb.X))
+		widths = append(widths, l.TextStyle.Width(lb.Label))
+	}
+	lanes, n := assignLanes(centers, widths)
+	out := make([]int, len(l.Labels))
+	for i := range out {
+		out[i] = -1
+	}
+	for k, i := range idx {
+		out[i] = lanes[k]
+	}
+	return out, n
+}
+
+// Plot implements the plot.Plotter interface. Leaders are drawn first and
+// each label gets a white backing so leaders to outer lanes pass behind
+// the labels of inner lanes.
+func (l *labelLanes) Plot(c draw.Canvas, plt *plot.Plot) {
+	trX, trY := plt.Transforms(&c)
+	type placed struct {
+		at    vg.Point
+		label string
+	}
+	var texts []placed
+	for _, top := range []bool{true, false} {
+		lanes, _ := l.layout(trX, top)
+		for i, lb := range l.Labels {
+			if lanes[i] < 0 {
+				continue
+			}
+			offset := (vg.Length(lanes[i]) + 0.5) * l.LaneHeight
+			y := c.Min.Y + offset
+			if top {
+				y = c.Max.Y - offset
+			}
+			x := trX(lb.X)
+			c.StrokeLine2(l.LineStyle, x, trY(lb.Y), x, y)
+			// Keep the text on the canvas; the leader stays on the point.
+			tx := clampBox(x, l.TextStyle.Width(lb.Label), c.Min.X, c.Max.X)
+			texts = append(texts, placed{vg.Point{X: tx, Y: y}, lb.Label})
+		}
+	}
+	for _, t := range texts {
+		r := l.TextStyle.Rectangle(t.label).Add(t.at)
+		c.FillPolygon(color.White, []vg.Point{
+			r.Min, {X: r.Max.X, Y: r.Min.Y}, r.Max, {X: r.Min.X, Y: r.Max.Y},
+		})
+		c.FillText(l.TextStyle, t.at, t.label)
+	}
+}
@@ -0,0 +1,38 @@
+package render
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"gonum.org/v1/plot/vg"
+)
+
+// namedDashes are the dash patterns accepted by name, in points.
+var namedDashes = map[string][]float64{
+	"solid":   nil,
+	"dashed":  {6, 3},
+	"dotted":  {1, 2},
+	"dashdot": {6, 2, 1, 2},
+}
+
+// parseDashes parses a dash pattern given as a name from namedDashes or as
+// comma-separated on/off lengths in points, e.g. "4,3".
+func parseDashes(s string) ([]vg.Length, error) {
+	s = strings.TrimSpace(s)
+	lengths, ok := namedDashes[s]
+	if !ok {
+		for _, f := range strings.Split(s, ",") {
+			v, err := strconv.ParseFloat(strings.TrimSpace(f), 64)
+			if err != nil || v <= 0 {
+				return nil, fmt.Errorf("%q: use solid, dashed, dotted, dashdot, or positive lengths like 4,3", s)
+			}
+			lengths = append(lengths, v)
+		}
+	}
+	var dashes []vg.Length
+	for _, v := range lengths {
+		dashes = append(dashes, vg.Points(v))
+	}
+	return dashes, nil
+}
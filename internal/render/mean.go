@@ -0,0 +1,50 @@
+package render
+
+import (
+	"fmt"
+	"image/color"
+
+	"gonum.org/v1/plot"
+	"gonum.org/v1/plot/font"
+	"gonum.org/v1/plot/vg"
+	"gonum.org/v1/plot/vg/draw"
+)
+
+// meanLine draws a dashed horizontal line across the plot at the mean
+// value, captioned at its right end. Its dash and color set it apart from
+// the solid grey zero axis.
+type meanLine struct {
+	Mean      float64
+	LineStyle draw.LineStyle
+	TextStyle draw.TextStyle
+}
+
+func newMeanLine(mean float64) *meanLine {
+	c := color.RGBA{A: 255, R: 150, G: 110, B: 60}
+	return &meanLine{
+		Mean: mean,
+		LineStyle: draw.LineStyle{
+			Color:  c,
+			Width:  vg.Points(0.75),
+			Dashes: []vg.Length{vg.Points(2), vg.Points(3)},
+		},
+		TextStyle: draw.TextStyle{
+			Color:   c,
+			Font:    font.From(plot.DefaultFont, vg.Points(7)),
+			XAlign:  draw.XRight,
+			YAlign:  draw.YBottom,
+			Handler: plot.DefaultTextHandler,
+		},
+	}
+}
+
+// Plot implements the plot.Plotter interface.
+func (m *meanLine) Plot(c draw.Canvas, plt *plot.Plot) {
+	_, trY := plt.Transforms(&c)
+	y := trY(m.Mean)
+	if y < c.Min.Y || y > c.Max.Y {
+		return
+	}
+	c.StrokeLine2(m.LineStyle, c.Min.X, y, c.Max.X, y)
+	c.FillText(m.TextStyle, vg.Point{X: c.Max.X, Y: y + vg.Points(1)}, fmt.Sprintf("avg %.1f", m.Mean))
+}
@@ -0,0 +1,51 @@
+package render
+
+import (
+	"fmt"
+	"strings"
+
+	"gonum.org/v1/plot/vg"
+
+	"github.com/rojaswestall/lifeline/internal/logging"
+)
+
+// rectsOverlap reports whether a and b share any area.
+func rectsOverlap(a, b vg.Rectangle) bool {
+	return a.Min.X < b.Max.X && b.Min.X < a.Max.X && a.Min.Y < b.Max.Y && b.Min.Y < a.Max.Y
+}
+
+// placedLabel is a caption's estimated box on the canvas (see plotView),
+// together with its point's marker box.
+type placedLabel struct {
+	Text   string
+	Year   float64
+	Box    vg.Rectangle
+	Marker vg.Rectangle
+}
+
+// labelOverlaps describes every pair of captions whose boxes intersect and
+// every caption that covers another point's marker, in placement order.
+func labelOverlaps(labels []placedLabel, markers []placedLabel) []string {
+	var found []string
+	for i, a := range labels {
+		for _, b := range labels[i+1:] {
+			if rectsOverlap(a.Box, b.Box) {
+				found = append(found, fmt.Sprintf("'%s'/'%s' near %g", a.Text, b.Text, a.Year))
+			}
+		}
+		for _, m := range markers {
+			if m.Text != a.Text && rectsOverlap(a.Box, m.Marker) {
+				found = append(found, fmt.Sprintf("'%s' covers the marker of '%s' near %g", a.Text, m.Text, m.Year))
+			}
+		}
+	}
+	return found
+}
+
+// reportOverlaps prints a one-line summary of labelOverlaps' findings.
+func reportOverlaps(found []string) {
+	if len(found) == 0 {
+		return
+	}
+	logging.Warnf("%d label overlap(s) detected: %s", len(found), strings.Join(found, ", "))
+}
@@ -0,0 +1,26 @@
+package render
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// parsePad parses a padding flag given either in data units ("0.5") or as
+// a percentage of the data span ("5%"). An empty string reports ok=false so
+// the caller can fall back to its default padding.
+func parsePad(s string, span float64) (pad float64, ok bool, err error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, false, nil
+	}
+	pct := strings.HasSuffix(s, "%")
+	v, err := strconv.ParseFloat(strings.TrimSuffix(s, "%"), 64)
+	if err != nil || v < 0 {
+		return 0, false, fmt.Errorf("%q: use a non-negative number or percentage", s)
+	}
+	if pct {
+		v = span * v / 100
+	}
+	return v, true, nil
+}
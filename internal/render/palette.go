@@ -0,0 +1,91 @@
+package render
+
+import (
+	"fmt"
+	"image/color"
+	"sort"
+	"strings"
+)
+
+// palette is the set of colors used wherever the tool picks a color
+// automatically. Colors given explicitly on the command line still win.
+type palette struct {
+	Description string
+
+	Line      color.RGBA // connecting line, span bars and error bars
+	Marker    color.RGBA
+	Highlight color.RGBA // markers of highlighted rows
+	Positive  color.RGBA // -shade-sign above zero
+	Negative  color.RGBA // -shade-sign below zero
+
+	// Series is cycled through for categorical data.
+	Series []color.RGBA
+}
+
+// Palettes is the registry of -palette names. Add an entry here to make a
+// new palette selectable.
+var Palettes = map[string]palette{
+	"default": {
+		Description: "soft blue line with green markers",
+		Line:        color.RGBA{A: 255, R: 100, G: 150, B: 200},
+		Marker:      color.RGBA{A: 255, R: 122, G: 195, B: 106},
+		Highlight:   color.RGBA{A: 255, R: 235, G: 30, B: 40},
+		Positive:    color.RGBA{A: 255, R: 60, G: 180, B: 75},
+		Negative:    color.RGBA{A: 255, R: 230, G: 25, B: 75},
+		Series: []color.RGBA{
+			{A: 255, R: 241, G: 90, B: 96},
+			{A: 255, R: 122, G: 195, B: 106},
+			{A: 255, R: 90, G: 155, B: 212},
+			{A: 255, R: 250, G: 167, B: 91},
+			{A: 255, R: 158, G: 103, B: 171},
+			{A: 255, R: 206, G: 112, B: 88},
+			{A: 255, R: 215, G: 127, B: 180},
+		},
+	},
+	"colorblind": {
+		Description: "Okabe-Ito colors, distinguishable with color vision deficiencies",
+		Line:        color.RGBA{A: 255, R: 0, G: 114, B: 178},
+		Marker:      color.RGBA{A: 255, R: 213, G: 94, B: 0},
+		Highlight:   color.RGBA{A: 255, R: 230, G: 159, B: 0},
+		Positive:    color.RGBA{A: 255, R: 86, G: 180, B: 233},
+		Negative:    color.RGBA{A: 255, R: 213, G: 94, B: 0},
+		Series: []color.RGBA{
+			{A: 255, R: 230, G: 159, B: 0},
+			{A: 255, R: 86, G: 180, B: 233},
+			{A: 255, R: 0, G: 158, B: 115},
+			{A: 255, R: 240, G: 228, B: 66},
+			{A: 255, R: 0, G: 114, B: 178},
+			{A: 255, R: 213, G: 94, B: 0},
+			{A: 255, R: 204, G: 121, B: 167},
+		},
+	},
+}
+
+// lookupPalette returns the registered palette with the given name.
+func lookupPalette(name string) (palette, error) {
+	pal, ok := Palettes[strings.ToLower(strings.TrimSpace(name))]
+	if !ok {
+		return palette{}, fmt.Errorf("unknown palette %q: use one of %s", name, strings.Join(PaletteNames(), ", "))
+	}
+	return pal, nil
+}
+
+// PaletteNames returns the registered palette names in sorted order.
+func PaletteNames() []string {
+	names := make([]string, 0, len(Palettes))
+	for name := range Palettes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// seriesColor returns the i-th series color, cycling when i runs past the end.
+func (pal palette) seriesColor(i int) color.RGBA {
+	return pal.Series[i%len(pal.Series)]
+}
+
+// withAlpha returns c with its opacity replaced by a.
+func withAlpha(c color.RGBA, a uint8) color.NRGBA {
+	return color.NRGBA{R: c.R, G: c.G, B: c.B, A: a}
+}
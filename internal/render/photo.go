@@ -0,0 +1,74 @@
+package render
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"os"
+	"path/filepath"
+
+	"gonum.org/v1/plot/vg"
+	vgdraw "gonum.org/v1/plot/vg/draw"
+)
+
+// loadPhoto decodes the image at path, resolved relative to dir, and crops
+// it to a centered circle with a transparent outside.
+func loadPhoto(dir, path string) (image.Image, error) {
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(dir, path)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	src, _, err := image.Decode(f)
+	if err != nil {
+		return nil, err
+	}
+	return circleCrop(src), nil
+}
+
+// circleCrop returns the largest centered square of src with everything
+// outside the inscribed circle made transparent.
+func circleCrop(src image.Image) image.Image {
+	b := src.Bounds()
+	side := min(b.Dx(), b.Dy())
+	origin := image.Point{X: b.Min.X + (b.Dx()-side)/2, Y: b.Min.Y + (b.Dy()-side)/2}
+	dst := image.NewRGBA(image.Rect(0, 0, side, side))
+	draw.DrawMask(dst, dst.Bounds(), src, origin, circleMask(side), image.Point{}, draw.Over)
+	return dst
+}
+
+// circleMask is an alpha mask that is opaque inside a circle inscribed in
+// a side×side square.
+type circleMask int
+
+func (m circleMask) ColorModel() color.Model { return color.AlphaModel }
+
+func (m circleMask) Bounds() image.Rectangle { return image.Rect(0, 0, int(m), int(m)) }
+
+func (m circleMask) At(x, y int) color.Color {
+	r := float64(m) / 2
+	dx, dy := float64(x)+0.5-r, float64(y)+0.5-r
+	if dx*dx+dy*dy <= r*r {
+		return color.Alpha{A: 255}
+	}
+	return color.Alpha{}
+}
+
+// photoGlyph draws a circular photo in place of a marker, with a thin ring
+// in the glyph color around it.
+type photoGlyph struct {
+	Img image.Image
+}
+
+// DrawGlyph implements the draw.GlyphDrawer interface.
+func (g photoGlyph) DrawGlyph(c *vgdraw.Canvas, sty vgdraw.GlyphStyle, pt vg.Point) {
+	r := sty.Radius
+	c.DrawImage(vg.Rectangle{
+		Min: vg.Point{X: pt.X - r, Y: pt.Y - r},
+		Max: vg.Point{X: pt.X + r, Y: pt.Y + r},
+	}, g.Img)
+	vgdraw.RingGlyph{}.DrawGlyph(c, sty, pt)
+}
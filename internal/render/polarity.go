@@ -0,0 +1,15 @@
+package render
+
+import (
+	"gonum.org/v1/plot/vg/draw"
+
+	"github.com/rojaswestall/lifeline/internal/timeline"
+)
+
+// polarityShapes are the marker shapes used by -polarity-style shape:
+// filled for good events, hollow for bad ones and a cross for neutral.
+var polarityShapes = map[timeline.Polarity]draw.GlyphDrawer{
+	timeline.Positive: draw.CircleGlyph{},
+	timeline.Negative: draw.RingGlyph{},
+	timeline.Neutral:  draw.CrossGlyph{},
+}
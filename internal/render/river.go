@@ -0,0 +1,73 @@
+package render
+
+import (
+	"image/color"
+	"math"
+
+	"gonum.org/v1/plot"
+	"gonum.org/v1/plot/plotter"
+	"gonum.org/v1/plot/vg"
+	"gonum.org/v1/plot/vg/draw"
+)
+
+// riverLine is a connecting line whose stroke width varies along its
+// length, interpolated between a width given at each point. plotter.Line
+// only supports a single width, so segments are filled as polygons.
+type riverLine struct {
+	plotter.XYs
+	Widths []vg.Length
+	Color  color.Color
+}
+
+// newRiverLine maps densities linearly onto widths between minW and maxW.
+// When all densities are equal every segment gets the midpoint width.
+func newRiverLine(xys plotter.XYs, densities []float64, minW, maxW vg.Length, c color.Color) *riverLine {
+	lo, hi := math.Inf(1), math.Inf(-1)
+	for _, d := range densities {
+		lo, hi = math.Min(lo, d), math.Max(hi, d)
+	}
+	widths := make([]vg.Length, len(densities))
+	for i, d := range densities {
+		t := 0.5
+		if hi > lo {
+			t = (d - lo) / (hi - lo)
+		}
+		widths[i] = minW + vg.Length(t)*(maxW-minW)
+	}
+	return &riverLine{XYs: xys, Widths: widths, Color: c}
+}
+
+// Plot implements the plot.Plotter interface.
+func (r *riverLine) Plot(c draw.Canvas, plt *plot.Plot) {
+	trX, trY := plt.Transforms(&c)
+	pts := make([]vg.Point, len(r.XYs))
+	for i, xy := range r.XYs {
+		pts[i] = vg.Point{X: trX(xy.X), Y: trY(xy.Y)}
+	}
+	for i := 1; i < len(pts); i++ {
+		a, b := pts[i-1], pts[i]
+		dx, dy := b.X-a.X, b.Y-a.Y
+		l := vg.Length(math.Hypot(float64(dx), float64(dy)))
+		if l == 0 {
+			continue
+		}
+		// Unit normal to the segment, scaled by half the width at each end.
+		nx, ny := -dy/l, dx/l
+		wa, wb := r.Widths[i-1]/2, r.Widths[i]/2
+		c.FillPolygon(r.Color, []vg.Point{
+			{X: a.X + nx*wa, Y: a.Y + ny*wa},
+			{X: b.X + nx*wb, Y: b.Y + ny*wb},
+			{X: b.X - nx*wb, Y: b.Y - ny*wb},
+			{X: a.X - nx*wa, Y: a.Y - ny*wa},
+		})
+	}
+	// Round joins so consecutive segments meet without notches.
+	for i, pt := range pts {
+		draw.CircleGlyph{}.DrawGlyph(&c, draw.GlyphStyle{Color: r.Color, Radius: r.Widths[i] / 2}, pt)
+	}
+}
+
+// DataRange implements the plot.DataRanger interface.
+func (r *riverLine) DataRange() (xmin, xmax, ymin, ymax float64) {
+	return plotter.XYRange(r.XYs)
+}
@@ -0,0 +1,33 @@
+package render
+
+import (
+	"path/filepath"
+	"strings"
+
+	"gonum.org/v1/plot"
+	"gonum.org/v1/plot/vg"
+	"gonum.org/v1/plot/vg/draw"
+)
+
+// Canvas renders p onto a canvas in the format of file's extension.
+func Canvas(p *plot.Plot, w, h, margin vg.Length, gray bool, footer *Footer, file string) (vg.CanvasWriterTo, error) {
+	format := strings.TrimPrefix(strings.ToLower(filepath.Ext(file)), ".")
+	c, err := draw.NewFormattedCanvas(w, h, format)
+	if err != nil {
+		return nil, err
+	}
+	var vc vg.CanvasSizer = c
+	if gray {
+		vc = grayCanvas{c}
+	}
+	dc := draw.New(vc)
+	if margin > 0 {
+		if p.BackgroundColor != nil {
+			dc.SetColor(p.BackgroundColor)
+			dc.Fill(dc.Rectangle.Path())
+		}
+		dc = draw.Crop(dc, margin, -margin, margin, -margin)
+	}
+	p.Draw(drawFooter(dc, footer))
+	return c, nil
+}
@@ -0,0 +1,93 @@
+package render
+
+import (
+	"image/color"
+
+	"gonum.org/v1/plot"
+	"gonum.org/v1/plot/plotter"
+	"gonum.org/v1/plot/vg"
+	"gonum.org/v1/plot/vg/draw"
+)
+
+// signShade fills the area between the line and y=0, in Positive where
+// the line is above zero and Negative where it is below. Segments that
+// cross zero are split at the interpolated crossing.
+type signShade struct {
+	XYs                plotter.XYs
+	Positive, Negative color.Color
+}
+
+// signRuns splits the line into runs that stay on one side of zero. Each
+// run is a polygon in data coordinates that starts and ends on y=0, and
+// above reports which side of zero each run is on.
+func signRuns(xys plotter.XYs) (runs []plotter.XYs, above []bool) {
+	if len(xys) < 2 {
+		return nil, nil
+	}
+	var cur plotter.XYs
+	curAbove := false
+	flush := func(endX float64) {
+		if len(cur) > 1 {
+			cur = append(cur, plotter.XY{X: endX})
+			runs = append(runs, cur)
+			above = append(above, curAbove)
+		}
+		cur = nil
+	}
+	start := func(x float64, up bool) {
+		cur = plotter.XYs{{X: x}}
+		curAbove = up
+	}
+	start(xys[0].X, xys[0].Y >= 0)
+	cur = append(cur, xys[0])
+	for i := 1; i < len(xys); i++ {
+		a, b := xys[i-1], xys[i]
+		if (a.Y > 0 && b.Y < 0) || (a.Y < 0 && b.Y > 0) {
+			xc := a.X + (b.X-a.X)*a.Y/(a.Y-b.Y)
+			flush(xc)
+			start(xc, b.Y > 0)
+		} else if a.Y == 0 && b.Y != 0 && (b.Y > 0) != curAbove {
+			flush(a.X)
+			start(a.X, b.Y > 0)
+		}
+		cur = append(cur, b)
+	}
+	flush(xys[len(xys)-1].X)
+	return runs, above
+}
+
+// Plot implements the plot.Plotter interface.
+func (s *signShade) Plot(c draw.Canvas, plt *plot.Plot) {
+	trX, trY := plt.Transforms(&c)
+	runs, above := signRuns(s.XYs)
+	for i, run := range runs {
+		poly := make([]vg.Point, len(run))
+		for j, xy := range run {
+			poly[j] = vg.Point{X: trX(xy.X), Y: trY(xy.Y)}
+		}
+		clr := s.Negative
+		if above[i] {
+			clr = s.Positive
+		}
+		c.FillPolygon(clr, c.ClipPolygonXY(poly))
+	}
+}
+
+// fractionAboveZero returns the share of the time span, measured in the
+// given (original) years, during which the straight-line interpolation
+// between points is above zero.
+func fractionAboveZero(xys plotter.XYs) float64 {
+	var pos, total float64
+	runs, above := signRuns(xys)
+	for i, run := range runs {
+		span := run[len(run)-1].X - run[0].X
+		total += span
+		if above[i] {
+			pos += span
+		}
+	}
+	if total == 0 {
+		return 0
+	}
+	return pos / total
+}
@@ -0,0 +1,85 @@
+package render
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+	"strconv"
+
+	"gonum.org/v1/plot"
+	"gonum.org/v1/plot/font"
+	"gonum.org/v1/plot/vg"
+	"gonum.org/v1/plot/vg/draw"
+
+	"github.com/rojaswestall/lifeline/internal/timeline"
+)
+
+// slopeNote captions one segment of the line with its change in value.
+type slopeNote struct {
+	X, Y float64 // plotted midpoint of the segment
+	Text string  // e.g. "+4 in 2y"
+	Up   bool
+}
+
+// findSlopes returns a note for every segment whose value changes by at
+// least threshold. Positions come from xs, but durations use the original
+// years so a gap squeezed by the density adjustment still reads honestly.
+func findSlopes(pts []timeline.Point, xs []float64, threshold float64) []slopeNote {
+	var notes []slopeNote
+	for i := 1; i < len(pts); i++ {
+		dv := pts[i].Value - pts[i-1].Value
+		if math.Abs(dv) < threshold || dv == 0 {
+			continue
+		}
+		dt := pts[i].Year - pts[i-1].Year
+		when := "in the same year"
+		if dt > 0 {
+			when = "in " + strconv.FormatFloat(math.Round(dt*10)/10, 'f', -1, 64) + "y"
+		}
+		notes = append(notes, slopeNote{
+			X:    (xs[i] + xs[i-1]) / 2,
+			Y:    (pts[i].Value + pts[i-1].Value) / 2,
+			Text: fmt.Sprintf("%+g %s", math.Round(dv*10)/10, when),
+			Up:   dv > 0,
+		})
+	}
+	return notes
+}
+
+// slopeNotes draws each note as a small colored arrow at the segment's
+// midpoint with its caption beside it.
+type slopeNotes struct {
+	Notes    []slopeNote
+	Up, Down color.Color
+
+	TextStyle draw.TextStyle
+}
+
+func newSlopeNotes(notes []slopeNote, up, down color.Color) *slopeNotes {
+	return &slopeNotes{
+		Notes: notes,
+		Up:    up,
+		Down:  down,
+		TextStyle: draw.TextStyle{
+			Color:   color.Gray{Y: 110},
+			Font:    font.From(plot.DefaultFont, vg.Points(7)),
+			XAlign:  draw.XLeft,
+			YAlign:  draw.YCenter,
+			Handler: plot.DefaultTextHandler,
+		},
+	}
+}
+
+// Plot implements the plot.Plotter interface.
+func (s *slopeNotes) Plot(c draw.Canvas, plt *plot.Plot) {
+	trX, trY := plt.Transforms(&c)
+	for _, n := range s.Notes {
+		pt := vg.Point{X: trX(n.X), Y: trY(n.Y)}
+		col := s.Up
+		if !n.Up {
+			col = s.Down
+		}
+		c.DrawGlyph(draw.GlyphStyle{Color: col, Radius: vg.Points(3), Shape: offScaleGlyph{Down: !n.Up}}, pt)
+		c.FillText(s.TextStyle, pt.Add(vg.Point{X: vg.Points(5)}), n.Text)
+	}
+}
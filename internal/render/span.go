@@ -0,0 +1,90 @@
+package render
+
+import (
+	"image/color"
+	"sort"
+
+	"gonum.org/v1/plot"
+	"gonum.org/v1/plot/font"
+	"gonum.org/v1/plot/vg"
+	"gonum.org/v1/plot/vg/draw"
+)
+
+// maxSpanLanes caps how many lanes the -span-lane strip may use. Spans
+// that don't fit are left out with a warning.
+const maxSpanLanes = 6
+
+// spanBar is one span drawn in the -span-lane strip.
+type spanBar struct {
+	X0, X1 float64 // plotted start and end
+	Lane   int
+	Label  string
+}
+
+// assignSpanLanes places each span in the lowest lane where it doesn't
+// overlap an earlier span. Spans that would need more than maxLanes lanes
+// are returned separately.
+func assignSpanLanes(bars []spanBar, maxLanes int) (placed, dropped []spanBar) {
+	sort.SliceStable(bars, func(i, j int) bool { return bars[i].X0 < bars[j].X0 })
+	var laneEnds []float64
+	for _, b := range bars {
+		lane := -1
+		for l, end := range laneEnds {
+			if b.X0 > end {
+				lane = l
+				break
+			}
+		}
+		if lane < 0 {
+			if len(laneEnds) == maxLanes {
+				dropped = append(dropped, b)
+				continue
+			}
+			laneEnds = append(laneEnds, 0)
+			lane = len(laneEnds) - 1
+		}
+		laneEnds[lane] = b.X1
+		b.Lane = lane
+		placed = append(placed, b)
+	}
+	return placed, dropped
+}
+
+// spanStrip draws span bars Gantt-style in a band below the chart. Lane 0
+// sits just under Top and each further lane is LaneHeight lower.
+type spanStrip struct {
+	Bars       []spanBar
+	Top        float64 // data y of the top of the band
+	LaneHeight float64 // data units per lane
+
+	Color     color.Color
+	TextStyle draw.TextStyle
+}
+
+func newSpanStrip(bars []spanBar, top, laneHeight float64, c color.Color) *spanStrip {
+	return &spanStrip{
+		Bars:       bars,
+		Top:        top,
+		LaneHeight: laneHeight,
+		Color:      c,
+		TextStyle: draw.TextStyle{
+			Color:   color.Gray{Y: 60},
+			Font:    font.From(plot.DefaultFont, vg.Points(7)),
+			XAlign:  draw.XLeft,
+			YAlign:  draw.YBottom,
+			Handler: plot.DefaultTextHandler,
+		},
+	}
+}
+
+// Plot implements the plot.Plotter interface.
+func (s *spanStrip) Plot(c draw.Canvas, plt *plot.Plot) {
+	trX, trY := plt.Transforms(&c)
+	for _, b := range s.Bars {
+		top := s.Top - float64(b.Lane)*s.LaneHeight
+		y0, y1 := trY(top-s.LaneHeight*0.75), trY(top-s.LaneHeight*0.45)
+		x0, x1 := trX(b.X0), trX(b.X1)
+		c.FillPolygon(s.Color, []vg.Point{{X: x0, Y: y0}, {X: x1, Y: y0}, {X: x1, Y: y1}, {X: x0, Y: y1}})
+		c.FillText(s.TextStyle, vg.Point{X: x0, Y: y1 + vg.Points(1)}, b.Label)
+	}
+}
@@ -0,0 +1,101 @@
+package render
+
+import (
+	"image/color"
+
+	"gonum.org/v1/plot"
+	"gonum.org/v1/plot/font"
+	"gonum.org/v1/plot/vg"
+	"gonum.org/v1/plot/vg/draw"
+)
+
+// stemLevels is how many staggered heights stems use on each side of the
+// axis before reusing the lowest free one.
+const stemLevels = 4
+
+// stemLabels draws the classic infographic timeline: each label sits in a
+// box on a vertical stem rising from the zero axis, alternating above and
+// below. Stem heights are staggered so boxes on the same side never
+// overlap horizontally while a free level is available.
+type stemLabels struct {
+	Xs     []float64
+	Labels []string
+
+	TextStyle draw.TextStyle
+	LineStyle draw.LineStyle
+	BoxColor  color.Color
+	Padding   vg.Length
+}
+
+func newStemLabels(xs []float64, labels []string, size vg.Length) *stemLabels {
+	return &stemLabels{
+		Xs:     xs,
+		Labels: labels,
+		TextStyle: draw.TextStyle{
+			Color:   color.Black,
+			Font:    font.From(plot.DefaultFont, size),
+			XAlign:  draw.XCenter,
+			YAlign:  draw.YCenter,
+			Handler: plot.DefaultTextHandler,
+		},
+		LineStyle: draw.LineStyle{Color: color.Gray{Y: 170}, Width: vg.Points(0.75)},
+		BoxColor:  color.Gray{Y: 200},
+		Padding:   vg.Points(3),
+	}
+}
+
+// Plot implements the plot.Plotter interface.
+func (s *stemLabels) Plot(c draw.Canvas, plt *plot.Plot) {
+	trX, trY := plt.Transforms(&c)
+	y0 := trY(0)
+	above := c.Max.Y - y0
+	below := y0 - c.Min.Y
+
+	// levelEnds[side][level] is the right edge of the last box placed there.
+	var levelEnds [2][stemLevels]vg.Length
+	for side := range levelEnds {
+		for l := range levelEnds[side] {
+			levelEnds[side][l] = c.Min.X - 1e6
+		}
+	}
+
+	for i, x := range s.Xs {
+		side := i % 2
+		cx := trX(x)
+		w := s.TextStyle.Width(s.Labels[i]) + 2*s.Padding
+		h := s.TextStyle.Height(s.Labels[i]) + 2*s.Padding
+
+		// Lowest level whose previous box ends before this one starts;
+		// if every level is taken, use the one that frees up first.
+		level := 0
+		for l := range stemLevels {
+			if levelEnds[side][l] < cx-w/2 {
+				level = l
+				break
+			}
+			if levelEnds[side][l] < levelEnds[side][level] {
+				level = l
+			}
+		}
+		levelEnds[side][level] = cx + w/2
+
+		room := above
+		dir := vg.Length(1)
+		if side == 1 {
+			room, dir = below, -1
+		}
+		step := (room - h) / stemLevels
+		center := y0 + dir*(step*vg.Length(level+1)+h/2-step/2)
+
+		c.StrokeLine2(s.LineStyle, cx, y0, cx, center-dir*h/2)
+		// The stem stays on the event; only the box shifts to stay on the canvas.
+		bx := clampBox(cx, w, c.Min.X, c.Max.X)
+		box := []vg.Point{
+			{X: bx - w/2, Y: center - h/2}, {X: bx + w/2, Y: center - h/2},
+			{X: bx + w/2, Y: center + h/2}, {X: bx - w/2, Y: center + h/2},
+		}
+		c.FillPolygon(color.White, box)
+		c.StrokeLines(draw.LineStyle{Color: s.BoxColor, Width: vg.Points(0.75)}, append(box, box[0]))
+		c.FillText(s.TextStyle, vg.Point{X: bx, Y: center}, s.Labels[i])
+	}
+}
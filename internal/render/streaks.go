@@ -0,0 +1,75 @@
+package render
+
+import (
+	"image/color"
+
+	"gonum.org/v1/plot"
+	"gonum.org/v1/plot/font"
+	"gonum.org/v1/plot/vg"
+	"gonum.org/v1/plot/vg/draw"
+
+	"github.com/rojaswestall/lifeline/internal/timeline"
+)
+
+// streakShade tints the background behind each streak, from the plotted
+// x of its first event to that of its last, and optionally captions it
+// along the bottom of the data area, where event labels are sparsest.
+type streakShade struct {
+	X0, X1   []float64
+	Good     []bool
+	Captions []string // empty to leave streaks uncaptioned
+
+	Positive, Negative color.Color
+	TextStyle          draw.TextStyle
+}
+
+// newStreakShade builds the shading for streaks of pts, which must carry
+// their plotted positions.
+func newStreakShade(pts []timeline.Point, streaks []timeline.Streak, pos, neg color.Color, captions bool) *streakShade {
+	sh := &streakShade{
+		Positive: pos,
+		Negative: neg,
+		TextStyle: draw.TextStyle{
+			Color:   color.Gray{Y: 130},
+			Font:    font.From(plot.DefaultFont, vg.Points(9)),
+			XAlign:  draw.XCenter,
+			YAlign:  draw.YBottom,
+			Handler: plot.DefaultTextHandler,
+		},
+	}
+	for _, s := range streaks {
+		x0, x1 := pts[s.First].PlotX, pts[s.Last].PlotX
+		if x1-x0 < 0.5 {
+			// A streak within one year still gets a visible band.
+			mid := (x0 + x1) / 2
+			x0, x1 = mid-0.25, mid+0.25
+		}
+		sh.X0 = append(sh.X0, x0)
+		sh.X1 = append(sh.X1, x1)
+		sh.Good = append(sh.Good, s.Polarity == timeline.Positive)
+		if captions {
+			sh.Captions = append(sh.Captions, timeline.StreakCaption(pts, s))
+		}
+	}
+	return sh
+}
+
+// Plot implements the plot.Plotter interface.
+func (sh *streakShade) Plot(c draw.Canvas, plt *plot.Plot) {
+	trX, _ := plt.Transforms(&c)
+	for i := range sh.X0 {
+		x0 := max(trX(sh.X0[i]), c.Min.X)
+		x1 := min(trX(sh.X1[i]), c.Max.X)
+		if x1 <= x0 {
+			continue
+		}
+		clr := sh.Negative
+		if sh.Good[i] {
+			clr = sh.Positive
+		}
+		c.FillPolygon(clr, []vg.Point{{X: x0, Y: c.Min.Y}, {X: x1, Y: c.Min.Y}, {X: x1, Y: c.Max.Y}, {X: x0, Y: c.Max.Y}})
+		if i < len(sh.Captions) {
+			c.FillText(sh.TextStyle, vg.Point{X: (x0 + x1) / 2, Y: c.Min.Y + vg.Points(4)}, sh.Captions[i])
+		}
+	}
+}
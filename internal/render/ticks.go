@@ -0,0 +1,93 @@
+package render
+
+import (
+	"math"
+	"strconv"
+
+	"gonum.org/v1/plot"
+	"gonum.org/v1/plot/vg"
+	"gonum.org/v1/plot/vg/draw"
+
+	"github.com/rojaswestall/lifeline/internal/adjust"
+)
+
+// yearTicker places x-axis ticks at exact multiples of Every original
+// years, mapped through the adjustment so each tick sits where that year
+// actually lands on the plot. With Minor set, unlabeled ticks are added
+// at every remaining whole year.
+type yearTicker struct {
+	Map   adjust.YearMap
+	Every float64
+	Minor bool
+}
+
+// Ticks implements the plot.Ticker interface.
+func (t yearTicker) Ticks(min, max float64) []plot.Tick {
+	first, last := t.Map.Year(min), t.Map.Year(max)
+	var ticks []plot.Tick
+	if t.Minor {
+		for y := math.Ceil(first); y <= last; y++ {
+			if math.Mod(y, t.Every) == 0 {
+				continue
+			}
+			ticks = append(ticks, plot.Tick{Value: t.Map.X(y)})
+		}
+	}
+	for y := math.Ceil(first/t.Every) * t.Every; y <= last; y += t.Every {
+		ticks = append(ticks, plot.Tick{Value: t.Map.X(y), Label: strconv.FormatFloat(y, 'f', -1, 64)})
+	}
+	return ticks
+}
+
+// tickSteps are the year intervals autoYearTicker chooses between.
+var tickSteps = []float64{1, 2, 5, 10, 20, 50, 100, 200, 500, 1000}
+
+// autoYearTicker is the default -years ticker. It picks the smallest step
+// from tickSteps at which neighbouring year labels, measured in TextStyle,
+// stay at least Gap apart across a data area Width wide and, when MaxLabels
+// is set, no more than MaxLabels labels are drawn. Ticks are then placed
+// like yearTicker. Since the adjustment stretches some stretches of time
+// and squeezes others, the check uses the tightest pair of labels.
+type autoYearTicker struct {
+	Map       adjust.YearMap
+	TextStyle draw.TextStyle
+	Width     vg.Length
+	Gap       vg.Length
+	MaxLabels int
+}
+
+// Ticks implements the plot.Ticker interface.
+func (t autoYearTicker) Ticks(lo, hi float64) []plot.Tick {
+	if hi <= lo {
+		return nil
+	}
+	first, last := t.Map.Year(lo), t.Map.Year(hi)
+	scale := t.Width / vg.Length(hi-lo)
+	spacing := func(step float64) vg.Length {
+		tightest := vg.Length(math.Inf(1))
+		prev := math.NaN()
+		for y := math.Ceil(first/step) * step; y <= last; y += step {
+			x := t.Map.X(y)
+			if !math.IsNaN(prev) {
+				tightest = min(tightest, vg.Length(x-prev)*scale)
+			}
+			prev = x
+		}
+		return tightest
+	}
+	need := t.TextStyle.Width(strconv.FormatFloat(math.Round(last), 'f', -1, 64)) + t.Gap
+	step := tickSteps[len(tickSteps)-1]
+	for _, s := range tickSteps {
+		labels := math.Floor(last/s) - math.Ceil(first/s) + 1
+		if t.MaxLabels > 0 && labels > float64(t.MaxLabels) {
+			continue
+		}
+		if spacing(s) >= need {
+			step = s
+			break
+		}
+	}
+	// Fill in unlabeled yearly ticks when they are far enough apart to read.
+	minor := step > 1 && spacing(1) >= vg.Points(3)
+	return yearTicker{Map: t.Map, Every: step, Minor: minor}.Ticks(lo, hi)
+}
@@ -0,0 +1,97 @@
+package render
+
+import (
+	"fmt"
+	"strings"
+
+	"gonum.org/v1/plot"
+	"gonum.org/v1/plot/text"
+	"gonum.org/v1/plot/vg"
+)
+
+// titleMargin is the gap kept between a left- or right-aligned title and
+// the canvas edge.
+const titleMargin = 10 // points
+
+// alignTitle positions the title horizontally. gonum always anchors the
+// title at the canvas center, so left and right alignment are expressed as
+// an XAlign fraction of the title width that moves it to the edge. Lines
+// of a wrapped title are aligned with each other the same way.
+func alignTitle(p *plot.Plot, align string, canvasWidth vg.Length) error {
+	width := p.Title.TextStyle.Width(p.Title.Text)
+	half := canvasWidth/2 - vg.Points(titleMargin)
+	block := blockText{Handler: p.Title.TextStyle.Handler, Align: 0.5}
+	switch align {
+	case "center":
+	case "left":
+		if width > 0 {
+			p.Title.TextStyle.XAlign = text.XAlignment(-half / width)
+		}
+		block.Align = 0
+	case "right":
+		if width > 0 {
+			p.Title.TextStyle.XAlign = text.XAlignment(half/width - 1)
+		}
+		block.Align = 1
+	default:
+		return fmt.Errorf("%q: use left, center, or right", align)
+	}
+	p.Title.TextStyle.Handler = block
+	return nil
+}
+
+// wrapTitle breaks s into lines no wider than maxWidth in sty, splitting
+// between words. A literal "\n" in s (as typed on the command line) or a
+// real newline always starts a new line.
+func wrapTitle(s string, sty text.Style, maxWidth vg.Length) string {
+	var lines []string
+	for _, para := range strings.Split(strings.ReplaceAll(s, `\n`, "\n"), "\n") {
+		line := ""
+		for _, word := range strings.Fields(para) {
+			if line != "" && sty.Width(line+" "+word) > maxWidth {
+				lines = append(lines, line)
+				line = word
+				continue
+			}
+			if line != "" {
+				line += " "
+			}
+			line += word
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// blockText draws multi-line text as a block: XAlign positions the block
+// by its widest line, and Align places each line within the block (0 left,
+// 0.5 centered, 1 right). gonum's plain handler instead aligns every line
+// by its own width, which scatters the lines of a left- or right-aligned
+// title.
+type blockText struct {
+	text.Handler
+	Align float64
+}
+
+// Draw implements the text.Handler interface.
+func (h blockText) Draw(c vg.Canvas, txt string, sty text.Style, pt vg.Point) {
+	txt = strings.TrimRight(txt, "\n")
+	lines := h.Lines(txt)
+	if len(lines) < 2 || sty.Rotation != 0 {
+		h.Handler.Draw(c, txt, sty, pt)
+		return
+	}
+
+	fnt := h.Cache().Lookup(sty.Font, sty.Font.Size)
+	var width vg.Length
+	for _, line := range lines {
+		width = max(width, fnt.Width(line))
+	}
+	c.SetColor(sty.Color)
+	pt.Y += sty.Height(txt)*vg.Length(sty.YAlign) - fnt.Extents().Ascent
+	for i, line := range lines {
+		x := pt.X + vg.Length(sty.XAlign)*width + vg.Length(h.Align)*(width-fnt.Width(line))
+		n := vg.Length(len(lines) - i)
+		c.FillString(fnt, vg.Point{X: x, Y: pt.Y + n*sty.Font.Size}, line)
+	}
+}
@@ -0,0 +1,41 @@
+package render
+
+import (
+	"fmt"
+
+	"github.com/rojaswestall/lifeline/internal/timeline"
+)
+
+// linearFit returns the least-squares line through the points' original
+// years and values, or ok=false when all points share one year.
+func linearFit(pts []timeline.Point) (slope, intercept float64, ok bool) {
+	var sx, sy, sxx, sxy float64
+	n := float64(len(pts))
+	for _, p := range pts {
+		sx += p.Year
+		sy += p.Value
+		sxx += p.Year * p.Year
+		sxy += p.Year * p.Value
+	}
+	d := n*sxx - sx*sx
+	if n < 2 || d == 0 {
+		return 0, 0, false
+	}
+	slope = (n*sxy - sx*sy) / d
+	return slope, (sy - slope*sx) / n, true
+}
+
+// projection is the trend extended to a future year.
+type projection struct {
+	Year, Value float64
+	Clamped     bool // Value was pulled back inside the -clamp range
+}
+
+// Label captions the projected endpoint.
+func (pr projection) Label() string {
+	s := fmt.Sprintf("projected: %+.1f in %g", pr.Value, pr.Year)
+	if pr.Clamped {
+		s += " (clamped)"
+	}
+	return s
+}
@@ -0,0 +1,41 @@
+package render
+
+import (
+	"image/color"
+
+	"gonum.org/v1/plot/plotter"
+	"gonum.org/v1/plot/vg"
+
+	"github.com/rojaswestall/lifeline/internal/timeline"
+)
+
+// errorBarData pairs positions with symmetric Y errors so it satisfies
+// both plotter.XYer and plotter.YErrorer.
+type errorBarData struct {
+	plotter.XYs
+	plotter.YErrors
+}
+
+// newUncertaintyBars returns thin error bars in color c for every point
+// with a positive uncertainty, or nil if there are none.
+func newUncertaintyBars(pts []timeline.Point, c color.Color) (*plotter.YErrorBars, error) {
+	var data errorBarData
+	for _, p := range pts {
+		if p.Uncertainty <= 0 {
+			continue
+		}
+		data.XYs = append(data.XYs, plotter.XY{X: p.PlotX, Y: p.Value})
+		data.YErrors = append(data.YErrors, struct{ Low, High float64 }{p.Uncertainty, p.Uncertainty})
+	}
+	if len(data.XYs) == 0 {
+		return nil, nil
+	}
+	bars, err := plotter.NewYErrorBars(data)
+	if err != nil {
+		return nil, err
+	}
+	bars.Width = vg.Points(0.75)
+	bars.Color = c
+	bars.CapWidth = vg.Points(4)
+	return bars, nil
+}
@@ -0,0 +1,90 @@
+package timeline
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+// Aggregation collapses all rows in one year or month into a single point.
+type Aggregation struct {
+	Unit string // "year" or "month"
+	Func string // "mean", "median", "min" or "max"
+}
+
+// ParseAggregation parses the -aggregate flag, e.g. "year:mean".
+func ParseAggregation(s string) (Aggregation, error) {
+	unit, fn, _ := strings.Cut(strings.ToLower(strings.TrimSpace(s)), ":")
+	a := Aggregation{Unit: unit, Func: fn}
+	if unit != "year" && unit != "month" {
+		return a, fmt.Errorf("%q: unit must be year or month", s)
+	}
+	switch fn {
+	case "mean", "median", "min", "max":
+	default:
+		return a, fmt.Errorf("%q: use mean, median, min or max after the colon", s)
+	}
+	return a, nil
+}
+
+// bucket returns the start of the year or month containing the decimal year y.
+func (a Aggregation) bucket(y float64) float64 {
+	if a.Unit == "month" {
+		return math.Floor(y*12+1e-9) / 12
+	}
+	return math.Floor(y)
+}
+
+// Apply collapses chronologically sorted pts into one point per bucket,
+// placed at the start of the bucket. A bucket with a single row keeps it
+// unchanged. Otherwise the point takes the label, importance, category
+// and fields of its most important row, highlighted rows winning ties
+// and then earlier ones. When every row is equally important and none is
+// highlighted, the label is a count such as "14 entries" instead.
+func (a Aggregation) Apply(pts []Point) []Point {
+	var out []Point
+	for i := 0; i < len(pts); {
+		key := a.bucket(pts[i].Year)
+		j := i
+		for j < len(pts) && a.bucket(pts[j].Year) == key {
+			j++
+		}
+		group := pts[i:j]
+		i = j
+		if len(group) == 1 {
+			out = append(out, group[0])
+			continue
+		}
+
+		vals := make([]float64, len(group))
+		for k, p := range group {
+			vals[k] = p.Value
+		}
+		sort.Float64s(vals)
+		p := Point{Year: key, Label: fmt.Sprintf("%d entries", len(group)), Tagged: true, Row: group[0].Row}
+		switch a.Func {
+		case "mean":
+			p.Value = Mean(group)
+		case "median":
+			p.Value = Percentile(vals, 0.5)
+		case "min":
+			p.Value = vals[0]
+		case "max":
+			p.Value = vals[len(vals)-1]
+		}
+		top, ranked := group[0], false
+		for _, g := range group[1:] {
+			ranked = ranked || g.Importance != top.Importance
+			if g.Importance > top.Importance || g.Importance == top.Importance && g.Highlight && !top.Highlight {
+				top = g
+			}
+		}
+		p.Importance, p.Category, p.Fields = top.Importance, top.Category, top.Fields
+		if ranked || top.Highlight {
+			p.Label, p.Highlight = top.Label, top.Highlight
+		}
+		out = append(out, p)
+	}
+	return out
+}
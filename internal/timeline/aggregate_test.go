@@ -0,0 +1,41 @@
+package timeline
+
+import "testing"
+
+func TestAggregationLabel(t *testing.T) {
+	row := func(year, imp float64, label, cat string, highlight bool) Point {
+		return Point{Year: year, Value: 2, Label: label, Importance: imp, Category: cat, Highlight: highlight,
+			Fields: map[string]string{"category": cat}}
+	}
+	for _, tc := range []struct {
+		name      string
+		group     []Point
+		label     string
+		category  string
+		highlight bool
+	}{
+		{"most important", []Point{row(2001.1, 1, "a", "work", true), row(2001.5, 3, "b", "home", false), row(2001.9, 2, "c", "work", false)}, "b", "home", false},
+		{"highlight breaks a tie", []Point{row(2001.1, 2, "a", "work", false), row(2001.5, 2, "b", "home", true)}, "b", "home", true},
+		{"earlier wins a tie", []Point{row(2001.1, 2, "a", "work", false), row(2001.5, 1, "b", "home", false), row(2001.9, 2, "c", "home", false)}, "a", "work", false},
+		{"nothing stands out", []Point{row(2001.1, 1, "a", "work", false), row(2001.5, 1, "b", "home", false)}, "2 entries", "work", false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			agg, err := ParseAggregation("year:mean")
+			if err != nil {
+				t.Fatal(err)
+			}
+			out := agg.Apply(tc.group)
+			if len(out) != 1 {
+				t.Fatalf("got %d points, want 1", len(out))
+			}
+			p := out[0]
+			if p.Label != tc.label || p.Category != tc.category || p.Fields["category"] != tc.category || p.Highlight != tc.highlight {
+				t.Errorf("got label %q, category %q, fields %v, highlight %v; want %q, %q, %v",
+					p.Label, p.Category, p.Fields, p.Highlight, tc.label, tc.category, tc.highlight)
+			}
+			if p.Year != 2001 || p.Value != 2 {
+				t.Errorf("got year %g, value %g; want 2001, 2", p.Year, p.Value)
+			}
+		})
+	}
+}
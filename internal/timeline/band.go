@@ -0,0 +1,118 @@
+package timeline
+
+import (
+	"fmt"
+	"math"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// BandRow holds the group percentiles for one year of a -band chart.
+type BandRow struct {
+	Year             float64
+	P25, Median, P75 float64
+}
+
+// BandFiles expands the -band flag, a comma-separated list of files or
+// glob patterns, into file names.
+func BandFiles(spec string) ([]string, error) {
+	var files []string
+	for _, pat := range strings.Split(spec, ",") {
+		pat = strings.TrimSpace(pat)
+		if pat == "" {
+			continue
+		}
+		m, err := filepath.Glob(pat)
+		if err != nil {
+			return nil, fmt.Errorf("%q: %w", pat, err)
+		}
+		if len(m) == 0 {
+			return nil, fmt.Errorf("%q: no such file", pat)
+		}
+		files = append(files, m...)
+	}
+	return files, nil
+}
+
+// YearlySeries interpolates pts linearly onto the whole years they cover.
+// Events sharing a year are averaged first.
+func YearlySeries(pts []Point) map[float64]float64 {
+	sorted := append([]Point(nil), pts...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Year < sorted[j].Year })
+
+	var years, vals []float64
+	for i := 0; i < len(sorted); {
+		j, sum := i, 0.0
+		for j < len(sorted) && sorted[j].Year == sorted[i].Year {
+			sum += sorted[j].Value
+			j++
+		}
+		years = append(years, sorted[i].Year)
+		vals = append(vals, sum/float64(j-i))
+		i = j
+	}
+
+	out := make(map[float64]float64)
+	if len(years) == 0 {
+		return out
+	}
+	k := 0
+	for y := math.Ceil(years[0]); y <= years[len(years)-1]; y++ {
+		for k+1 < len(years) && years[k+1] < y {
+			k++
+		}
+		if k+1 == len(years) || years[k] == y {
+			out[y] = vals[k]
+			continue
+		}
+		t := (y - years[k]) / (years[k+1] - years[k])
+		out[y] = vals[k] + t*(vals[k+1]-vals[k])
+	}
+	return out
+}
+
+// PercentileBand combines yearly series into per-year 25th, 50th and 75th
+// percentiles. Years covered by fewer than minSeries series are left out.
+func PercentileBand(series []map[float64]float64, minSeries int) []BandRow {
+	byYear := make(map[float64][]float64)
+	for _, s := range series {
+		for y, v := range s {
+			byYear[y] = append(byYear[y], v)
+		}
+	}
+	var rows []BandRow
+	for y, vs := range byYear {
+		if len(vs) < minSeries {
+			continue
+		}
+		sort.Float64s(vs)
+		rows = append(rows, BandRow{Year: y, P25: Percentile(vs, 0.25), Median: Percentile(vs, 0.5), P75: Percentile(vs, 0.75)})
+	}
+	sort.SliceStable(rows, func(i, j int) bool { return rows[i].Year < rows[j].Year })
+	return rows
+}
+
+// Percentile returns the q-th quantile of sorted vs, interpolating
+// between neighbouring values.
+func Percentile(vs []float64, q float64) float64 {
+	pos := q * float64(len(vs)-1)
+	i := int(pos)
+	if i+1 >= len(vs) {
+		return vs[len(vs)-1]
+	}
+	return vs[i] + (pos-float64(i))*(vs[i+1]-vs[i])
+}
+
+// BandRuns splits rows into runs of consecutive years, so excluded years
+// leave a gap in the band instead of being bridged.
+func BandRuns(rows []BandRow) [][]BandRow {
+	var runs [][]BandRow
+	for i, r := range rows {
+		if i == 0 || r.Year-rows[i-1].Year > 1 {
+			runs = append(runs, nil)
+		}
+		runs[len(runs)-1] = append(runs[len(runs)-1], r)
+	}
+	return runs
+}
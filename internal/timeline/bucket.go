@@ -0,0 +1,48 @@
+package timeline
+
+import (
+	"fmt"
+	"math"
+)
+
+// BucketYears groups chronologically sorted pts into bins of size years,
+// aligned to multiples of size (so 5 gives 1995-1999, 2000-2004, ...).
+// Each bin becomes one point at its center with the mean value and Count
+// set to the number of events in it. With label "range" the point is
+// captioned with the bin's years; with "top" it takes the label of the
+// first highlighted event or else the one furthest from zero.
+func BucketYears(pts []Point, size int, label string) []Point {
+	var out []Point
+	for i := 0; i < len(pts); {
+		start := math.Floor(pts[i].Year/float64(size)) * float64(size)
+		j := i
+		for j < len(pts) && pts[j].Year < start+float64(size) {
+			j++
+		}
+		group := pts[i:j]
+		i = j
+
+		p := Point{
+			Year:   start + float64(size)/2,
+			Value:  Mean(group),
+			Label:  fmt.Sprintf("%g–%g", start, start+float64(size)-1),
+			Tagged: true,
+			Row:    group[0].Row,
+			Count:  len(group),
+		}
+		for _, g := range group {
+			p.Importance = max(p.Importance, g.Importance)
+		}
+		if label == "top" {
+			top := group[0]
+			for _, g := range group[1:] {
+				if !top.Highlight && (g.Highlight || math.Abs(g.Value) > math.Abs(top.Value)) {
+					top = g
+				}
+			}
+			p.Label, p.Highlight = top.Label, top.Highlight
+		}
+		out = append(out, p)
+	}
+	return out
+}
@@ -0,0 +1,21 @@
+package timeline
+
+import "sort"
+
+// ReadCategories sets each point's Category from the named column (a
+// header name or 1-based column number) and returns the distinct
+// categories in sorted order. Points with an empty cell have no category.
+func ReadCategories(pts []Point, col string) []string {
+	seen := make(map[string]bool)
+	var names []string
+	for i := range pts {
+		c := pts[i].Fields[col]
+		pts[i].Category = c
+		if c != "" && !seen[c] {
+			seen[c] = true
+			names = append(names, c)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,50 @@
+package timeline
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ParseRange parses a "MIN:MAX" range such as "-10:10".
+func ParseRange(s string) (lo, hi float64, err error) {
+	a, b, ok := strings.Cut(strings.TrimSpace(s), ":")
+	if !ok {
+		return 0, 0, fmt.Errorf("%q: expected MIN:MAX", s)
+	}
+	lo, err = strconv.ParseFloat(strings.TrimSpace(a), 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("%q: invalid minimum", s)
+	}
+	hi, err = strconv.ParseFloat(strings.TrimSpace(b), 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("%q: invalid maximum", s)
+	}
+	if lo >= hi {
+		return 0, 0, fmt.Errorf("%q: minimum must be less than maximum", s)
+	}
+	return lo, hi, nil
+}
+
+// ClampPoints limits every value to [lo, hi], marking clamped points with
+// the direction they went off scale and noting the real value in their
+// label. It returns how many points were clamped below and above.
+func ClampPoints(pts []Point, lo, hi float64) (below, above int) {
+	for i := range pts {
+		v := pts[i].Value
+		switch {
+		case v > hi:
+			pts[i].Value = hi
+			pts[i].OffScale = 1
+			above++
+		case v < lo:
+			pts[i].Value = lo
+			pts[i].OffScale = -1
+			below++
+		default:
+			continue
+		}
+		pts[i].Label += fmt.Sprintf(" (off scale: %s)", strconv.FormatFloat(v, 'f', -1, 64))
+	}
+	return below, above
+}
@@ -0,0 +1,60 @@
+package timeline
+
+import (
+	"math"
+	"strings"
+	"unicode"
+)
+
+// dupStopWords are ignored when comparing labels for likely duplicates.
+var dupStopWords = map[string]bool{
+	"a": true, "an": true, "the": true, "at": true, "in": true, "on": true,
+	"of": true, "to": true, "for": true, "and": true, "with": true, "my": true,
+}
+
+// labelTokens returns the distinct lowercased words of a label, without
+// punctuation or stop words.
+func labelTokens(label string) map[string]bool {
+	tokens := make(map[string]bool)
+	for _, w := range strings.FieldsFunc(strings.ToLower(label), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	}) {
+		if !dupStopWords[w] {
+			tokens[w] = true
+		}
+	}
+	return tokens
+}
+
+// tokenOverlap is the share of the shorter label's words found in the
+// other label, from 0 to 1.
+func tokenOverlap(a, b map[string]bool) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+	shared := 0
+	for w := range a {
+		if b[w] {
+			shared++
+		}
+	}
+	return float64(shared) / float64(min(len(a), len(b)))
+}
+
+// FindDuplicates returns index pairs of events in the same year whose
+// values differ by at most one and whose labels share at least half their
+// words. Only labels from the CSV are compared. pts must be sorted by year.
+func FindDuplicates(pts []Point) [][2]int {
+	var pairs [][2]int
+	for i := range pts {
+		for j := i + 1; j < len(pts) && pts[j].Year == pts[i].Year; j++ {
+			if !pts[i].Tagged || !pts[j].Tagged || math.Abs(pts[i].Value-pts[j].Value) > 1 {
+				continue
+			}
+			if tokenOverlap(labelTokens(pts[i].Label), labelTokens(pts[j].Label)) >= 0.5 {
+				pairs = append(pairs, [2]int{i, j})
+			}
+		}
+	}
+	return pairs
+}
@@ -0,0 +1,42 @@
+package timeline
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ParseNow parses the -now flag as a decimal year. An empty string means
+// the current date.
+func ParseNow(s string) (float64, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return decimalYear(time.Now()), nil
+	}
+	y, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%q: use a decimal year such as 2024.5", s)
+	}
+	return y, nil
+}
+
+// decimalYear returns t as a year plus the elapsed fraction of that year.
+func decimalYear(t time.Time) float64 {
+	start := time.Date(t.Year(), time.January, 1, 0, 0, 0, 0, t.Location())
+	end := start.AddDate(1, 0, 0)
+	return float64(t.Year()) + float64(t.Sub(start))/float64(end.Sub(start))
+}
+
+// DatedOutside returns the indexes of points that start before lo or
+// start or end after hi, the likely result of a mistyped year.
+func DatedOutside(pts []Point, lo, hi float64) []int {
+	var out []int
+	for i, p := range pts {
+		if p.Year < lo || math.Max(p.Year, p.End) > hi {
+			out = append(out, i)
+		}
+	}
+	return out
+}
@@ -0,0 +1,21 @@
+package timeline
+
+import (
+	"strconv"
+	"strings"
+)
+
+// parseHighlight reports whether a row is emphasized, either by a leading
+// '!' on its label (which is stripped) or a truthy "highlight" column.
+func parseHighlight(label string, fields map[string]string) (string, bool) {
+	if rest, ok := strings.CutPrefix(label, "!"); ok {
+		return strings.TrimSpace(rest), true
+	}
+	switch v := strings.ToLower(fields["highlight"]); v {
+	case "yes", "y", "x":
+		return label, true
+	default:
+		b, _ := strconv.ParseBool(v)
+		return label, b
+	}
+}
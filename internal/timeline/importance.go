@@ -0,0 +1,29 @@
+package timeline
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// ReadImportance sets each point's Importance from the named column (a
+// header name or 1-based column number) and reports whether any row had
+// one. Rows without a value count as 1.
+func ReadImportance(pts []Point, col string) (found bool, err error) {
+	for i := range pts {
+		pts[i].Importance = 1
+		s := pts[i].Fields[col]
+		if s == "" {
+			continue
+		}
+		v, err := strconv.ParseFloat(s, 64)
+		if err != nil || v < 0 {
+			return found, fmt.Errorf("row %d: invalid importance %q in column %s: use a non-negative number", pts[i].Row, s, col)
+		}
+		pts[i].Importance = v
+		if err := validatePoint(pts[i]); err != nil {
+			return found, fmt.Errorf("row %d: %w", pts[i].Row, err)
+		}
+		found = true
+	}
+	return found, nil
+}
@@ -0,0 +1,71 @@
+package timeline
+
+import (
+	"math"
+	"sort"
+)
+
+// InterpolateYears fills every whole year missing between two consecutive
+// points that are more than a year apart with a linearly interpolated
+// point, marked Interpolated. pts must be sorted by year.
+func InterpolateYears(pts []Point) (out []Point, added int) {
+	for i, p := range pts {
+		if i > 0 {
+			prev := pts[i-1]
+			if p.Year-prev.Year > 1 {
+				for y := float64(int(prev.Year) + 1); y < p.Year; y++ {
+					if y <= prev.Year {
+						continue
+					}
+					t := (y - prev.Year) / (p.Year - prev.Year)
+					out = append(out, Point{
+						Year:         y,
+						Value:        prev.Value + t*(p.Value-prev.Value),
+						Label:        "",
+						Row:          p.Row,
+						Importance:   1,
+						Interpolated: true,
+					})
+					added++
+				}
+			}
+		}
+		out = append(out, p)
+	}
+	return out, added
+}
+
+// Observed returns the points that came from the input, leaving out
+// interpolated ones.
+func Observed(pts []Point) []Point {
+	var obs []Point
+	for _, p := range pts {
+		if !p.Interpolated {
+			obs = append(obs, p)
+		}
+	}
+	return obs
+}
+
+// FillZeroYears adds an unlabeled zero-valued point, marked ZeroFilled,
+// for every whole year in the span of pts that has no point in it. pts
+// must be sorted by year; the result is too.
+func FillZeroYears(pts []Point) (out []Point, added int) {
+	if len(pts) == 0 {
+		return pts, 0
+	}
+	have := make(map[float64]bool)
+	for _, p := range pts {
+		have[math.Floor(p.Year)] = true
+	}
+	first, last := math.Ceil(pts[0].Year), math.Floor(pts[len(pts)-1].Year)
+	out = append([]Point(nil), pts...)
+	for y := first; y <= last; y++ {
+		if !have[y] {
+			out = append(out, Point{Year: y, Importance: 1, ZeroFilled: true})
+			added++
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Year < out[j].Year })
+	return out, added
+}
@@ -0,0 +1,10 @@
+package timeline
+
+// Mean returns the average value of pts.
+func Mean(pts []Point) float64 {
+	sum := 0.0
+	for _, p := range pts {
+		sum += p.Value
+	}
+	return sum / float64(len(pts))
+}
@@ -0,0 +1,23 @@
+package timeline
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+)
+
+// monthNames are the abbreviations FormatYear uses.
+var monthNames = [12]string{"Jan", "Feb", "Mar", "Apr", "May", "Jun", "Jul", "Aug", "Sep", "Oct", "Nov", "Dec"}
+
+// FormatYear prints a decimal year for people. Whole years print as they
+// are. With months set, a fractional year prints as the month it falls
+// in, the same month -aggregate month would put it in, so 2018.5 reads
+// "Jul 2018"; otherwise it prints as written.
+func FormatYear(y float64, months bool) string {
+	if !months || y == math.Trunc(y) {
+		return strconv.FormatFloat(y, 'f', -1, 64)
+	}
+	m := math.Floor(y*12 + 1e-9)
+	year := math.Floor(m / 12)
+	return fmt.Sprintf("%s %.0f", monthNames[int(m-year*12)], year)
+}
@@ -0,0 +1,111 @@
+package timeline
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// Normalization linearly maps values from one range onto another, e.g. a
+// 0-100 score onto the -10..10 frame used for hand-scored events.
+type Normalization struct {
+	FromLo, FromHi float64
+	ToLo, ToHi     float64
+	// Reject makes values outside the source range an error instead of
+	// clamping them to its ends.
+	Reject bool
+	// File limits the mapping to the input of that name; see SameFile.
+	// It is empty for a mapping of every input.
+	File string
+}
+
+// ParseNormalization parses the -normalize flag: space-separated
+// from=MIN:MAX, to=MIN:MAX (default -10:10), out=clamp|reject (default
+// clamp) and file=NAME. from is required.
+func ParseNormalization(s string) (Normalization, error) {
+	n := Normalization{ToLo: -10, ToHi: 10}
+	hasFrom := false
+	for _, field := range strings.Fields(s) {
+		key, val, ok := strings.Cut(field, "=")
+		if !ok {
+			return n, fmt.Errorf("%q: expected key=value", field)
+		}
+		var err error
+		switch key {
+		case "from":
+			n.FromLo, n.FromHi, err = ParseRange(val)
+			hasFrom = true
+		case "to":
+			n.ToLo, n.ToHi, err = ParseRange(val)
+		case "file":
+			n.File = val
+		case "out":
+			switch val {
+			case "clamp":
+				n.Reject = false
+			case "reject":
+				n.Reject = true
+			default:
+				err = fmt.Errorf("%q: use clamp or reject", val)
+			}
+		default:
+			err = fmt.Errorf("unknown key %q: use from, to, out or file", key)
+		}
+		if err != nil {
+			return n, err
+		}
+	}
+	if !hasFrom {
+		return n, fmt.Errorf("%q: from=MIN:MAX is required", s)
+	}
+	return n, nil
+}
+
+// String describes the mapping for the console log.
+func (n Normalization) String() string {
+	return fmt.Sprintf("%g:%g -> %g:%g", n.FromLo, n.FromHi, n.ToLo, n.ToHi)
+}
+
+// Apply maps the values of pts in place. Out-of-range values are clamped
+// or, with Reject, reported with their row number.
+func (n Normalization) Apply(pts []Point) error {
+	for i := range pts {
+		v := pts[i].Value
+		if v < n.FromLo || v > n.FromHi {
+			if n.Reject {
+				return fmt.Errorf("row %d: value %g is outside %g:%g", pts[i].Row, v, n.FromLo, n.FromHi)
+			}
+			v = max(n.FromLo, min(n.FromHi, v))
+		}
+		pts[i].Value = n.ToLo + (v-n.FromLo)/(n.FromHi-n.FromLo)*(n.ToHi-n.ToLo)
+	}
+	return nil
+}
+
+// PercentScale is the mapping -auto-scale applies to 0-100 scores, so 50
+// lands on the zero line.
+var PercentScale = Normalization{FromLo: 0, FromHi: 100, ToLo: -10, ToHi: 10}
+
+// LooksLikePercent reports whether pts look like 0-100 scores: no value
+// below 0 or above 100, and at least one above 10. Data that already fits
+// the -10..10 frame never qualifies.
+func LooksLikePercent(pts []Point) bool {
+	above := false
+	for _, p := range pts {
+		if p.Value < 0 || p.Value > 100 {
+			return false
+		}
+		above = above || p.Value > 10
+	}
+	return above
+}
+
+// SameFile reports whether name, given to a per-file flag such as
+// -normalize file=NAME, refers to input: the same path, or the same file
+// name when name has no directory.
+func SameFile(name, input string) bool {
+	if filepath.Clean(name) == filepath.Clean(input) {
+		return true
+	}
+	return filepath.Base(name) == name && filepath.Base(input) == name
+}
@@ -0,0 +1,50 @@
+package timeline
+
+import (
+	"math"
+	"sort"
+)
+
+// FindOutliers returns the indexes of points whose value lies more than k
+// median absolute deviations from the median. When at least half the
+// values are equal the deviation is zero and nothing is reported, since
+// any spread at all would count as an outlier.
+func FindOutliers(pts []Point, k float64) []int {
+	if len(pts) < 3 {
+		return nil
+	}
+	vals := make([]float64, len(pts))
+	for i, p := range pts {
+		vals[i] = p.Value
+	}
+	sort.Float64s(vals)
+	med := Percentile(vals, 0.5)
+	for i, v := range vals {
+		vals[i] = math.Abs(v - med)
+	}
+	sort.Float64s(vals)
+	mad := Percentile(vals, 0.5)
+	if mad == 0 {
+		return nil
+	}
+	var out []int
+	for i, p := range pts {
+		if math.Abs(p.Value-med) > k*mad {
+			out = append(out, i)
+		}
+	}
+	return out
+}
+
+// DropIndexes returns pts without the points at the given sorted indexes.
+func DropIndexes(pts []Point, idx []int) []Point {
+	kept := make([]Point, 0, len(pts)-len(idx))
+	for i, p := range pts {
+		if len(idx) > 0 && idx[0] == i {
+			idx = idx[1:]
+			continue
+		}
+		kept = append(kept, p)
+	}
+	return kept
+}
@@ -0,0 +1,72 @@
+package timeline
+
+import "fmt"
+
+// Polarity is the sign of an event: good, bad or neither. Features that
+// treat positive and negative events differently should use it rather
+// than testing the value themselves.
+type Polarity int
+
+const (
+	Neutral Polarity = iota
+	Positive
+	Negative
+)
+
+// Polarity derives the point's polarity from its current value.
+func (p Point) Polarity() Polarity {
+	switch {
+	case p.Value > 0:
+		return Positive
+	case p.Value < 0:
+		return Negative
+	}
+	return Neutral
+}
+
+func (pol Polarity) String() string {
+	switch pol {
+	case Positive:
+		return "positive"
+	case Negative:
+		return "negative"
+	}
+	return "neutral"
+}
+
+// Sign returns "+" or "-", or "" for neutral events.
+func (pol Polarity) Sign() string {
+	switch pol {
+	case Positive:
+		return "+"
+	case Negative:
+		return "-"
+	}
+	return ""
+}
+
+// PolarityCounts tallies points by polarity.
+type PolarityCounts struct {
+	Positive int `json:"positive"`
+	Negative int `json:"negative"`
+	Neutral  int `json:"neutral"`
+}
+
+func CountPolarity(pts []Point) PolarityCounts {
+	var c PolarityCounts
+	for _, p := range pts {
+		switch p.Polarity() {
+		case Positive:
+			c.Positive++
+		case Negative:
+			c.Negative++
+		default:
+			c.Neutral++
+		}
+	}
+	return c
+}
+
+func (c PolarityCounts) String() string {
+	return fmt.Sprintf("%d good / %d bad / %d neutral", c.Positive, c.Negative, c.Neutral)
+}
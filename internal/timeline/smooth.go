@@ -0,0 +1,35 @@
+package timeline
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ParseSmoothing parses the -smooth-data flag, "median:N", returning the
+// window size N.
+func ParseSmoothing(s string) (int, error) {
+	kind, n, _ := strings.Cut(strings.TrimSpace(s), ":")
+	w, err := strconv.Atoi(n)
+	if kind != "median" || err != nil || w < 1 {
+		return 0, fmt.Errorf("%q: use median:N with N a positive number of points", s)
+	}
+	return w, nil
+}
+
+// MovingMedian returns the median of each value and its neighbors in a
+// window of the given size centered on it. Near the ends the window is cut
+// short rather than dropping points.
+func MovingMedian(vals []float64, window int) []float64 {
+	out := make([]float64, len(vals))
+	before := (window - 1) / 2
+	after := window - 1 - before
+	for i := range vals {
+		lo, hi := max(0, i-before), min(len(vals), i+after+1)
+		w := append([]float64(nil), vals[lo:hi]...)
+		sort.Float64s(w)
+		out[i] = Percentile(w, 0.5)
+	}
+	return out
+}
@@ -0,0 +1,35 @@
+package timeline
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// ParseYearSpan parses a year cell, which is either a single year or a
+// span "start-end" (an en dash also works). end is zero for single years.
+func ParseYearSpan(s string) (year, end float64, err error) {
+	year, err = strconv.ParseFloat(s, 64)
+	if err == nil {
+		return year, 0, nil
+	}
+	// Skip the first byte so a leading minus sign isn't taken as the separator.
+	sep := strings.IndexAny(s[min(1, len(s)):], "-–")
+	if sep < 0 {
+		return 0, 0, err
+	}
+	sep++
+	startStr, endStr := s[:sep], strings.TrimLeft(s[sep:], "-–")
+	year, err = strconv.ParseFloat(strings.TrimSpace(startStr), 64)
+	if err != nil {
+		return 0, 0, err
+	}
+	end, err = strconv.ParseFloat(strings.TrimSpace(endStr), 64)
+	if err != nil {
+		return 0, 0, err
+	}
+	if end <= year {
+		return 0, 0, errors.New("span must end after it starts")
+	}
+	return year, end, nil
+}
@@ -0,0 +1,61 @@
+package timeline
+
+import (
+	"fmt"
+	"math"
+)
+
+// Streak is a run of consecutive events all above or all below zero.
+// First and Last index the events it spans.
+type Streak struct {
+	First, Last int
+	Polarity    Polarity
+}
+
+// StreakYears is the length of a streak in original years, counting the
+// calendar years it touches, so events in 2010, 2011 and 2012 make a
+// three-year streak.
+func StreakYears(pts []Point, s Streak) float64 {
+	return math.Floor(pts[s.Last].Year) - math.Floor(pts[s.First].Year) + 1
+}
+
+// FindStreaks returns the runs of chronologically sorted pts that stay on
+// one side of zero for at least minYears. A zero value ends a run.
+func FindStreaks(pts []Point, minYears float64) []Streak {
+	var out []Streak
+	for i := 0; i < len(pts); {
+		pol := pts[i].Polarity()
+		j := i
+		for j+1 < len(pts) && pts[j+1].Polarity() == pol {
+			j++
+		}
+		s := Streak{First: i, Last: j, Polarity: pol}
+		if pol != Neutral && StreakYears(pts, s) >= minYears {
+			out = append(out, s)
+		}
+		i = j + 1
+	}
+	return out
+}
+
+// LongestStreak returns the longest streak of the given polarity, the
+// earliest on ties, and false if there is none.
+func LongestStreak(streaks []Streak, pts []Point, pol Polarity) (Streak, bool) {
+	var best Streak
+	found := false
+	for _, s := range streaks {
+		if s.Polarity == pol && (!found || StreakYears(pts, s) > StreakYears(pts, best)) {
+			best, found = s, true
+		}
+	}
+	return best, found
+}
+
+// StreakCaption describes a streak, e.g. "3-year good streak".
+func StreakCaption(pts []Point, s Streak) string {
+	kind := "good"
+	if s.Polarity == Negative {
+		kind = "bad"
+	}
+	return fmt.Sprintf("%g-year %s streak", StreakYears(pts, s), kind)
+}
@@ -0,0 +1,237 @@
+// Package timeline reads lifeline CSV files into points and holds the
+// transformations applied to them before they are plotted.
+package timeline
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"image"
+	"io"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Point represents one CSV row.
+type Point struct {
+	Year  float64
+	Value float64
+	Label string
+	// End is the last year of a span row written as "start-end" in the
+	// year column, and zero for single events.
+	End float64
+	// Interpolated marks points added by -interpolate for missing years.
+	// They are drawn but never labeled, logged or counted in summaries.
+	Interpolated bool
+	// ZeroFilled marks unlabeled zero points added by -fill-zero.
+	ZeroFilled bool
+	// Importance weighs the event with -density-weighted; see
+	// ReadImportance.
+	Importance float64
+	// Count is the number of events a -bucket point stands for, and zero
+	// for ordinary points.
+	Count int
+	// Tagged reports whether the label was provided in the CSV rather
+	// than generated from the year and value.
+	Tagged bool
+	// OffScale is +1 or -1 when Value was clamped to the top or bottom of
+	// the -clamp range, and 0 otherwise.
+	OffScale int
+	// Uncertainty is the symmetric error read from -uncertainty-col.
+	Uncertainty float64
+	// Fields holds the columns after the label; see ReadCSV.
+	Fields map[string]string
+	// PlotX is the x position the point is drawn at once the same-year
+	// and density adjustments have run. Year is never adjusted; anything
+	// shown to the user, such as labels and log lines, uses Year.
+	PlotX float64
+	// Row is the 1-based CSV row the point was read from.
+	Row int
+	// Photo, when set, is drawn in place of the marker (see -photo-col).
+	Photo image.Image
+	// Category is read from -category-col and picks the marker color and
+	// shape.
+	Category string
+	// Highlight marks rows whose label started with '!' or that have a
+	// truthy "highlight" column; they get bold labels and brighter markers.
+	Highlight bool
+}
+
+// ReadCSV loads points from a CSV file. Each row is:
+// year,value[,label[,...]]
+// The year may also be a span such as 2010-2014.
+// An optional header row whose first cell is "year" names the columns.
+// Columns after the label are kept in Point.Fields, keyed both by their
+// 1-based position ("4", "5", ...) and, when there is a header, by their
+// lowercased header name. Lines starting with '#' are comments, and row
+// numbers in errors and Point.Row are the line a row starts on.
+func ReadCSV(path string) ([]Point, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	rows, lines, err := ReadRecords(f)
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, errors.New("empty CSV")
+	}
+
+	header := CSVHeader(rows[0])
+	first := 0
+	if header != nil {
+		first = 1
+	}
+
+	var pts []Point
+	for i := first; i < len(rows); i++ {
+		pt, err := ParseRow(rows[i], header, lines[i])
+		if err != nil {
+			return nil, fmt.Errorf("row %d: %w", lines[i], err)
+		}
+		pts = append(pts, pt)
+	}
+	return pts, nil
+}
+
+// NewCSVReader returns a reader set up for lifeline CSVs: rows may have
+// any number of fields and lines starting with '#' are comments.
+func NewCSVReader(r io.Reader) *csv.Reader {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.Comment = '#'
+	return cr
+}
+
+// ReadRecords reads every record from r with NewCSVReader, along with the
+// 1-based line each one starts on.
+func ReadRecords(r io.Reader) (rows [][]string, lines []int, err error) {
+	cr := NewCSVReader(r)
+	for {
+		row, err := cr.Read()
+		if err == io.EOF {
+			return rows, lines, nil
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		line, _ := cr.FieldPos(0)
+		rows = append(rows, row)
+		lines = append(lines, line)
+	}
+}
+
+// CSVHeader returns the lowercased column names when row is a header,
+// that is when its first cell is "year", and nil otherwise.
+func CSVHeader(row []string) []string {
+	if len(row) == 0 || !strings.EqualFold(strings.TrimSpace(row[0]), "year") {
+		return nil
+	}
+	header := make([]string, len(row))
+	for i, name := range row {
+		header[i] = strings.ToLower(strings.TrimSpace(name))
+	}
+	return header
+}
+
+// ParseRow turns one data row into a point; see ReadCSV for the layout.
+// line is the 1-based row number stored in Point.Row.
+func ParseRow(row, header []string, line int) (Point, error) {
+	if len(row) < 2 {
+		return Point{}, fmt.Errorf("expected at least 2 columns, got %d", len(row))
+	}
+	yearStr := strings.TrimSpace(row[0])
+	valStr := strings.TrimSpace(row[1])
+
+	year, end, err := ParseYearSpan(yearStr)
+	if err != nil {
+		return Point{}, fmt.Errorf("invalid year %q: %w", yearStr, err)
+	}
+
+	val, err := strconv.ParseFloat(valStr, 64)
+	if err != nil {
+		return Point{}, fmt.Errorf("invalid value %q: %w", valStr, err)
+	}
+
+	lbl := ""
+	if len(row) >= 3 {
+		lbl = strings.TrimSpace(row[2])
+	}
+
+	var fields map[string]string
+	if len(row) > 3 {
+		fields = make(map[string]string)
+		for c := 3; c < len(row); c++ {
+			v := strings.TrimSpace(row[c])
+			fields[strconv.Itoa(c+1)] = v
+			if c < len(header) && header[c] != "" {
+				fields[header[c]] = v
+			}
+		}
+	}
+
+	// A label of just "!" highlights the event and keeps the generated
+	// caption.
+	lbl, highlight := parseHighlight(lbl, fields)
+	tagged := lbl != ""
+	if !tagged {
+		lbl = AutoLabel(year, val, false)
+	}
+
+	pt := Point{Year: year, End: end, Value: val, Label: lbl, Tagged: tagged, Fields: fields, Row: line, Highlight: highlight}
+	if err := validatePoint(pt); err != nil {
+		return Point{}, err
+	}
+	return pt, nil
+}
+
+// AutoLabel is the caption for rows without a label. With months set, a
+// fractional year shows as a month; see FormatYear.
+func AutoLabel(year, val float64, months bool) string {
+	if months && year != math.Trunc(year) {
+		return fmt.Sprintf("%s, %.2f", FormatYear(year, true), val)
+	}
+	return fmt.Sprintf("%.0f, %.2f", year, val)
+}
+
+// Load reads a CSV with ReadCSV and sorts the points by year, so
+// the connecting line goes left to right in time. Rows from the same year
+// keep their order in the file.
+func Load(path string) ([]Point, error) {
+	pts, err := ReadCSV(path)
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(pts, func(i, j int) bool { return pts[i].Year < pts[j].Year })
+	return pts, nil
+}
+
+// OrderSameYear reorders events that share a year, leaving the years
+// themselves in place: "input" keeps the CSV order, "value" sorts from
+// lowest to highest and "label" alphabetically, ignoring case.
+func OrderSameYear(pts []Point, by string) error {
+	var less func(a, b Point) bool
+	switch by {
+	case "input":
+		return nil
+	case "value":
+		less = func(a, b Point) bool { return a.Value < b.Value }
+	case "label":
+		less = func(a, b Point) bool { return strings.ToLower(a.Label) < strings.ToLower(b.Label) }
+	default:
+		return fmt.Errorf("%q: use input, value or label", by)
+	}
+	sort.SliceStable(pts, func(i, j int) bool {
+		if pts[i].Year != pts[j].Year {
+			return pts[i].Year < pts[j].Year
+		}
+		return less(pts[i], pts[j])
+	})
+	return nil
+}
@@ -0,0 +1,77 @@
+package timeline
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeCSV writes data to a new file and returns its name.
+func writeCSV(t *testing.T, data string) string {
+	t.Helper()
+	name := filepath.Join(t.TempDir(), "in.csv")
+	if err := os.WriteFile(name, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return name
+}
+
+func TestReadCSVRejectsNonFinite(t *testing.T) {
+	for _, tc := range []struct {
+		row, want string
+	}{
+		{"2003,inf,Too good", "row 3: value is +Inf"},
+		{"2003,-Inf,Too bad", "row 3: value is -Inf"},
+		{"2003,NaN,Unsure", "row 3: value is NaN"},
+		{"Inf,2,Someday", "row 3: year is +Inf"},
+	} {
+		_, err := ReadCSV(writeCSV(t, "2001,1,Start\n2002,2,Next\n"+tc.row+"\n2004,3,After\n"))
+		if err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("%s: got error %v, want one containing %q", tc.row, err, tc.want)
+		}
+	}
+}
+
+func TestReadUncertaintyRejectsNonFinite(t *testing.T) {
+	for _, tc := range []struct {
+		cell, want string
+	}{
+		{"inf", "row 4: uncertainty is +Inf"},
+		{"-inf", "row 4: uncertainty is +Inf"},
+		{"NaN", "row 4: uncertainty is NaN"},
+	} {
+		pts, err := ReadCSV(writeCSV(t, "year,value,label,err\n2001,1,Start,0.5\n2002,2,Next,\n2003,4,Peak,"+tc.cell+"\n"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = ReadUncertainty(pts, "err")
+		if err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("%s: got error %v, want one containing %q", tc.cell, err, tc.want)
+		}
+	}
+}
+
+func TestParseRowLabels(t *testing.T) {
+	for _, tc := range []struct {
+		label     string
+		want      string
+		tagged    bool
+		highlight bool
+	}{
+		{"Moves out", "Moves out", true, false},
+		{"", "2003, 4.00", false, false},
+		{"!Moves out", "Moves out", true, true},
+		{"! Moves out", "Moves out", true, true},
+		{"!", "2003, 4.00", false, true},
+		{"! ", "2003, 4.00", false, true},
+	} {
+		p, err := ParseRow([]string{"2003", "4", tc.label}, nil, 2)
+		if err != nil {
+			t.Fatalf("%q: %v", tc.label, err)
+		}
+		if p.Label != tc.want || p.Tagged != tc.tagged || p.Highlight != tc.highlight {
+			t.Errorf("%q: label %q, tagged %v, highlight %v; want %q, %v, %v", tc.label, p.Label, p.Tagged, p.Highlight, tc.want, tc.tagged, tc.highlight)
+		}
+	}
+}
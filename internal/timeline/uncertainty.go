@@ -0,0 +1,28 @@
+package timeline
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+)
+
+// ReadUncertainty sets each point's Uncertainty from the named column
+// (a header name or 1-based column number). Missing or empty cells mean
+// no uncertainty.
+func ReadUncertainty(pts []Point, col string) error {
+	for i := range pts {
+		s := pts[i].Fields[col]
+		if s == "" {
+			continue
+		}
+		e, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return fmt.Errorf("%q (year %g): invalid uncertainty %q in column %s", pts[i].Label, pts[i].Year, s, col)
+		}
+		pts[i].Uncertainty = math.Abs(e)
+		if err := validatePoint(pts[i]); err != nil {
+			return fmt.Errorf("row %d: %w", pts[i].Row, err)
+		}
+	}
+	return nil
+}
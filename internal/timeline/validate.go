@@ -0,0 +1,28 @@
+package timeline
+
+import (
+	"fmt"
+	"math"
+)
+
+// validatePoint rejects points with a NaN or infinite number in any
+// numeric field. strconv.ParseFloat accepts "NaN" and "Inf", so every
+// input reader should run its points through here before they reach the
+// range and layout code.
+func validatePoint(p Point) error {
+	for _, f := range []struct {
+		name string
+		v    float64
+	}{
+		{"year", p.Year},
+		{"end year", p.End},
+		{"value", p.Value},
+		{"uncertainty", p.Uncertainty},
+		{"importance", p.Importance},
+	} {
+		if math.IsNaN(f.v) || math.IsInf(f.v, 0) {
+			return fmt.Errorf("%s is %v, expected a finite number", f.name, f.v)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,281 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/rojaswestall/lifeline/internal/exit"
+	"github.com/rojaswestall/lifeline/internal/timeline"
+)
+
+// lintFinding is one problem reported by the lint subcommand. Row is the
+// line the CSV row starts on, or 0 for findings about the file as a whole.
+type lintFinding struct {
+	Row      int
+	Severity string // "error", "warning" or "info"
+	Message  string
+}
+
+// lintOptions are the thresholds used by lintRows.
+type lintOptions struct {
+	// Values outside [Lo, Hi] are reported.
+	Lo, Hi float64
+	// MaxLabel is the longest label, in characters, not reported as long.
+	MaxLabel int
+	// OutlierK is passed to timeline.FindOutliers.
+	OutlierK float64
+}
+
+// lintInput is a CSV file as read by readLintRows.
+type lintInput struct {
+	Rows  [][]string // nil for records that are not valid CSV
+	Lines []int      // the line each row starts on
+	// Comments holds the comment lines before each row, keyed by row
+	// index; the key len(Rows) holds those after the last row.
+	Comments map[int][]string
+	Found    []lintFinding
+}
+
+// readLintRows reads every record of a CSV file like timeline.ReadRecords, but
+// keeps going past malformed ones, reporting them as findings. Comment
+// lines are kept so -fix can write them back.
+func readLintRows(data []byte) (lintInput, error) {
+	in := lintInput{Comments: make(map[int][]string)}
+	cr := timeline.NewCSVReader(bytes.NewReader(data))
+	// Comments sit between the end of one record and the start of the next.
+	var prev int64
+	takeComments := func(end int64) {
+		for _, l := range strings.Split(string(data[prev:end]), "\n") {
+			if strings.HasPrefix(l, "#") {
+				in.Comments[len(in.Rows)] = append(in.Comments[len(in.Rows)], strings.TrimRight(l, "\r"))
+			} else if strings.TrimSpace(l) != "" {
+				break
+			}
+		}
+		prev = end
+	}
+	for {
+		row, err := cr.Read()
+		takeComments(cr.InputOffset())
+		if err == io.EOF {
+			return in, nil
+		}
+		var pe *csv.ParseError
+		if errors.As(err, &pe) {
+			in.Rows = append(in.Rows, nil)
+			in.Lines = append(in.Lines, pe.StartLine)
+			in.Found = append(in.Found, lintFinding{pe.StartLine, "error", pe.Err.Error()})
+			continue
+		}
+		if err != nil {
+			return in, err
+		}
+		line, _ := cr.FieldPos(0)
+		in.Rows = append(in.Rows, row)
+		in.Lines = append(in.Lines, line)
+	}
+}
+
+// lintRows checks rows the way the render path would read them, through
+// timeline.CSVHeader and timeline.ParseRow, and adds the softer checks
+// that rendering only warns about or does not do at all. lines holds the
+// line each row starts on. It returns the points that parsed.
+func lintRows(rows [][]string, lines []int, opts lintOptions) ([]timeline.Point, []lintFinding) {
+	var found []lintFinding
+	add := func(row int, sev, format string, args ...any) {
+		found = append(found, lintFinding{row, sev, fmt.Sprintf(format, args...)})
+	}
+
+	var header []string
+	first := 0
+	if len(rows) > 0 {
+		if header = timeline.CSVHeader(rows[0]); header != nil {
+			first = 1
+		}
+	}
+
+	var pts []timeline.Point
+	seen := make(map[string]int)
+	for i := first; i < len(rows); i++ {
+		if rows[i] == nil {
+			continue // already reported by readLintRows
+		}
+		p, err := timeline.ParseRow(rows[i], header, lines[i])
+		if err != nil {
+			add(lines[i], "error", "%v", err)
+			continue
+		}
+
+		key := fmt.Sprintf("%g|%g|%g|%s", p.Year, p.End, p.Value, p.Label)
+		if prev, ok := seen[key]; ok {
+			add(p.Row, "warning", "same year, value and label as row %d", prev)
+		} else {
+			seen[key] = p.Row
+		}
+		if len(pts) > 0 && p.Year < pts[len(pts)-1].Year {
+			add(p.Row, "info", "year %g comes after %g in the file; rows are sorted by year before drawing", p.Year, pts[len(pts)-1].Year)
+		}
+		if !p.Tagged {
+			add(p.Row, "info", "no label; it will read %q", p.Label)
+		} else if n := utf8.RuneCountInString(p.Label); n > opts.MaxLabel {
+			add(p.Row, "warning", "label is %d characters long (more than %d)", n, opts.MaxLabel)
+		}
+		if p.Value < opts.Lo || p.Value > opts.Hi {
+			add(p.Row, "warning", "value %g is outside %g:%g", p.Value, opts.Lo, opts.Hi)
+		}
+		pts = append(pts, p)
+	}
+	if len(pts) == 0 {
+		add(0, "error", "no data rows")
+		return pts, found
+	}
+
+	sorted := append([]timeline.Point(nil), pts...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Year < sorted[j].Year })
+	for _, i := range timeline.FindOutliers(sorted, opts.OutlierK) {
+		add(sorted[i].Row, "warning", "value %g is an outlier", sorted[i].Value)
+	}
+	for _, d := range timeline.FindDuplicates(sorted) {
+		a, b := sorted[d[0]], sorted[d[1]]
+		if a.Value != b.Value || a.Label != b.Label {
+			add(b.Row, "warning", "possible duplicate of row %d %q", a.Row, a.Label)
+		}
+	}
+	return pts, found
+}
+
+// writeFixedCSV writes in's rows with surrounding whitespace trimmed from
+// every cell, letting csv.Writer choose the quoting. Comment lines are
+// written back where they were.
+func writeFixedCSV(path string, in lintInput) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	w := csv.NewWriter(f)
+	comments := func(i int) {
+		w.Flush()
+		for _, c := range in.Comments[i] {
+			fmt.Fprintln(f, c)
+		}
+	}
+	for i, row := range in.Rows {
+		comments(i)
+		fixed := make([]string, len(row))
+		for j, cell := range row {
+			fixed[j] = strings.TrimSpace(cell)
+		}
+		w.Write(fixed)
+	}
+	comments(len(in.Rows))
+	w.Flush()
+	if err := w.Error(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// lintFlags are the flags of the lint subcommand.
+type lintFlags struct {
+	fs        *flag.FlagSet
+	rangeFlag *string
+	maxLabel  *int
+	outlierK  *float64
+	fixPath   *string
+}
+
+// newLintFlags defines the lint flags on a new FlagSet.
+func newLintFlags() lintFlags {
+	fs := flag.NewFlagSet("lint", flag.ContinueOnError)
+	return lintFlags{
+		fs:        fs,
+		rangeFlag: fs.String("range", "-10:10", "warn about values outside `MIN:MAX`"),
+		maxLabel:  fs.Int("max-label", 40, "warn about labels longer than this many characters"),
+		outlierK:  fs.Float64("outlier-k", 10, "warn about values more than this many median absolute deviations from the median"),
+		fixPath:   fs.String("fix", "", "write a copy with whitespace trimmed and quoting normalized to `FILE`"),
+	}
+}
+
+// runLint implements `lifeline lint [-fix out.csv] input.csv`. It reports
+// every finding at once and returns exit.Input only if one of them is an
+// error.
+func runLint(args []string) (int, error) {
+	f := newLintFlags()
+	fs := f.fs
+	if err := parseFlags(fs, args); err != nil {
+		return flagStatus(err)
+	}
+	if fs.NArg() != 1 {
+		return exit.Usage, errors.New("lint takes one input file")
+	}
+	input := fs.Arg(0)
+
+	lo, hi, err := timeline.ParseRange(*f.rangeFlag)
+	if err != nil {
+		return exit.Usage, fmt.Errorf("invalid -range: %v", err)
+	}
+	opts := lintOptions{Lo: lo, Hi: hi, MaxLabel: *f.maxLabel, OutlierK: *f.outlierK}
+	if *f.outlierK <= 0 {
+		return exit.Usage, fmt.Errorf("invalid -outlier-k %g: must be positive", *f.outlierK)
+	}
+	if *f.fixPath != "" {
+		if a, b := filepath.Clean(*f.fixPath), filepath.Clean(input); a == b {
+			return exit.Usage, errors.New("-fix must name a new file, not the input")
+		}
+	}
+
+	data, err := os.ReadFile(input)
+	if err != nil {
+		return exit.Input, err
+	}
+	in, err := readLintRows(data)
+	if err != nil {
+		return exit.Input, err
+	}
+	rows := in.Rows
+	pts, more := lintRows(rows, in.Lines, opts)
+	found := append(in.Found, more...)
+	// File-wide findings go last, after the rows.
+	sort.SliceStable(found, func(i, j int) bool {
+		a, b := found[i].Row, found[j].Row
+		return a != 0 && (b == 0 || a < b)
+	})
+
+	counts := make(map[string]int)
+	for _, fd := range found {
+		counts[fd.Severity]++
+		where := input
+		if fd.Row > 0 {
+			where = fmt.Sprintf("%s:%d", input, fd.Row)
+		}
+		fmt.Printf("%s: %s: %s\n", where, fd.Severity, fd.Message)
+	}
+	fmt.Printf("%d rows checked, %d points: %d error(s), %d warning(s), %d note(s)\n",
+		len(rows), len(pts), counts["error"], counts["warning"], counts["info"])
+
+	if *f.fixPath != "" {
+		for _, row := range rows {
+			if row == nil {
+				return exit.Input, errors.New("not writing -fix output: some rows are not valid CSV")
+			}
+		}
+		if err := writeFixedCSV(*f.fixPath, in); err != nil {
+			return exit.Render, err
+		}
+		fmt.Printf("Wrote %s\n", *f.fixPath)
+	}
+	if counts["error"] > 0 {
+		return exit.Input, nil
+	}
+	return exit.OK, nil
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestLintRange(t *testing.T) {
+	inTempDir(t)
+	const csv = "2001,3,Moves out\n2005,42,Starts a band\n2009,-1,Graduates\n"
+	if err := os.WriteFile("events.csv", []byte(csv), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range []struct {
+		args []string
+		want string // the range warning, or "" for none
+	}{
+		{[]string{"lint", "events.csv"}, "events.csv:2: warning: value 42 is outside -10:10\n"},
+		{[]string{"lint", "-range", "0:10", "events.csv"}, "events.csv:3: warning: value -1 is outside 0:10\n"},
+		{[]string{"lint", "-range", "-100:100", "events.csv"}, ""},
+	} {
+		out := outputOf(t, &os.Stdout, func() {
+			if code, err := run(tc.args); err != nil || code != 0 {
+				t.Errorf("run(%q) = %d, %v", tc.args, code, err)
+			}
+		})
+		if tc.want != "" && !strings.Contains(out, tc.want) {
+			t.Errorf("%q: no %q in the output:\n%s", tc.args, tc.want, out)
+		}
+		if tc.want == "" && strings.Contains(out, "is outside") {
+			t.Errorf("%q: unexpected range warning:\n%s", tc.args, out)
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/rojaswestall/lifeline/internal/adjust"
+	"github.com/rojaswestall/lifeline/internal/logging"
+	"github.com/rojaswestall/lifeline/internal/timeline"
+)
+
+// runSummary is the closing record of a run.
+type runSummary struct {
+	Charts   []chartSummary `json:"charts"`
+	Warnings int            `json:"warnings"`
+	DryRun   bool           `json:"dry_run"` // nothing was written
+}
+
+// chartSummary describes one chart a run drew. The ranges are of the
+// plotted events, after any normalizing, clamping and the like.
+type chartSummary struct {
+	Output   string  `json:"output"`
+	Points   int     `json:"points"`
+	Adjusted int     `json:"adjusted"`
+	Overlaps int     `json:"overlaps"`
+	YearMin  float64 `json:"year_min"`
+	YearMax  float64 `json:"year_max"`
+	ValueMin float64 `json:"value_min"`
+	ValueMax float64 `json:"value_max"`
+}
+
+// newChartSummary summarizes the chart of pts drawn to output.
+func newChartSummary(output string, pts []timeline.Point, adj []adjust.Adjustment, overlaps int) chartSummary {
+	s := chartSummary{Output: output, Points: len(pts), Adjusted: adjust.Moved(adj), Overlaps: overlaps}
+	for i, p := range pts {
+		if i == 0 {
+			s.YearMin, s.YearMax, s.ValueMin, s.ValueMax = p.Year, p.Year, p.Value, p.Value
+			continue
+		}
+		s.YearMin, s.YearMax = min(s.YearMin, p.Year), max(s.YearMax, p.Year)
+		s.ValueMin, s.ValueMax = min(s.ValueMin, p.Value), max(s.ValueMax, p.Value)
+	}
+	return s
+}
+
+// logSummary writes the closing summary record, with -log-json.
+func logSummary(s runSummary) {
+	if !logging.JSONRecords() {
+		return
+	}
+	s.Warnings = logging.Warnings()
+	logging.Emit(logging.Record{Event: "summary", Summary: &s})
+}
+
+// adjustmentSummary is the line printed after drawing a chart to output,
+// e.g. "42 points, 7 adjusted, wrote out.png".
+func adjustmentSummary(adj []adjust.Adjustment, output string, dryRun bool) string {
+	verb := "wrote"
+	if dryRun {
+		verb = "would write"
+	}
+	return fmt.Sprintf("%d points, %d adjusted, %s %s", len(adj), adjust.Moved(adj), verb, output)
+}
@@ -1,406 +1,609 @@
 package main
 
 import (
-	"encoding/csv"
 	"errors"
 	"flag"
 	"fmt"
-	"image/color"
-	"log"
-	"math"
+	"io"
 	"os"
 	"path/filepath"
-	"sort"
-	"strconv"
 	"strings"
+	"text/template"
+	"time"
 
-	"gonum.org/v1/plot"
-	"gonum.org/v1/plot/plotter"
-	"gonum.org/v1/plot/plotutil"
 	"gonum.org/v1/plot/vg"
+
+	"github.com/rojaswestall/lifeline/internal/adjust"
+	"github.com/rojaswestall/lifeline/internal/exit"
+	"github.com/rojaswestall/lifeline/internal/logging"
+	"github.com/rojaswestall/lifeline/internal/render"
+	"github.com/rojaswestall/lifeline/internal/timeline"
 )
 
-// Point represents one CSV row.
-type Point struct {
-	Year  float64
-	Value float64
-	Label string
+// defaultOutput is the image drawn from input when no output is named:
+// input with its extension, if any, replaced by .png. Dots in directory
+// names and a leading dot in the file name don't start an extension.
+func defaultOutput(input string) string {
+	ext := filepath.Ext(input)
+	if base := filepath.Base(input); ext == base {
+		ext = ""
+	}
+	return strings.TrimSuffix(input, ext) + ".png"
 }
 
-// readCSV loads points from a CSV file. Each row is:
-// year,value[,label]
-func readCSV(path string) ([]Point, error) {
-	f, err := os.Open(path)
+func main() {
+	code, err := run(os.Args[1:])
 	if err != nil {
-		return nil, err
+		logging.ReportError(err)
+		if code == exit.Usage {
+			fmt.Fprintln(os.Stderr, usageText(os.Args[1:]))
+		}
 	}
-	defer f.Close()
+	os.Exit(code)
+}
 
-	r := csv.NewReader(f)
-	r.FieldsPerRecord = -1 // allow 2 or 3 fields
-	rows, err := r.ReadAll()
-	if err != nil {
-		return nil, err
+// renderFlags are the flags of a render, which grid and config show
+// share. Those that shape the chart itself set chart; the rest decide
+// what is written where and how much is reported.
+type renderFlags struct {
+	fs    *flag.FlagSet
+	chart *render.Options
+
+	title, footer, output   *string
+	verbose, quiet, logJSON *bool
+	version, dryRun, open   *bool
+	force, backup, watch    *bool
+	report                  *string
+	splitDir, splitFormat   *string
+	splitTitle              *string
+	failOnOverlap           *bool
+	cols                    *int
+	names                   *string
+	alignYears              *bool
+}
+
+// newRenderFlags defines the render flags on a new FlagSet.
+func newRenderFlags() renderFlags {
+	fs := flag.NewFlagSet(filepath.Base(os.Args[0]), flag.ContinueOnError)
+	o := new(render.Options)
+	f := renderFlags{fs: fs, chart: o}
+
+	fs.BoolVar(&o.ShowYears, "years", false, "show years on x-axis")
+	f.title = fs.String("title", "My Life Line", "title for the timeline; may use {{.Count}}, {{.FirstYear}}, {{.LastYear}}, {{.RenderDate}} and {{.Input}}")
+	fs.BoolVar(&o.Decades, "decades", false, "draw small tick marks at decade boundaries along the zero line")
+	fs.BoolVar(&o.DecadeLabels, "decade-labels", false, "caption decade tick marks with their year (implies -decades)")
+	fs.Float64Var(&o.TickEvery, "tick-every", 0, "with -years, place a labeled tick every N years (0 picks the densest step whose labels do not overlap)")
+	fs.BoolVar(&o.MinorTicks, "minor-ticks", false, "with -tick-every, add unlabeled ticks at every year in between")
+	fs.StringVar(&o.Grid, "grid", "both", "grid lines to draw: off, horizontal, vertical, or both")
+	fs.StringVar(&o.GridColor, "grid-color", "", "grid line color as hex or name (default light greys)")
+	fs.StringVar(&o.GridStyle, "grid-style", "solid", "grid line style: solid or dotted")
+	fs.BoolVar(&o.Arrow, "arrow", false, "end the zero axis line in an arrowhead past the last event")
+	fs.Float64Var(&o.ArrowExtend, "arrow-extend", 0, "with -arrow, years to extend the axis past the last event (0 for a small margin)")
+	fs.Float64Var(&o.LabelSize, "label-size", 9, "label font size in points")
+	fs.Float64Var(&o.TitleSize, "title-size", 12, "title font size in points")
+	fs.StringVar(&o.TitleColor, "title-color", "black", "title color as hex or name")
+	fs.StringVar(&o.TitleAlign, "title-align", "center", "title alignment: left, center, or right")
+	fs.BoolVar(&o.NoTitle, "no-title", false, "omit the title and reclaim its space")
+	fs.StringVar(&o.PadX, "pad-x", "", "padding beyond the data on the x-axis, in years or percent of the span (e.g. 1 or 5%)")
+	fs.StringVar(&o.PadY, "pad-y", "", "padding beyond the data on the y-axis, in value units or percent of the range")
+	fs.Float64Var(&o.Margin, "margin", 0, "whitespace between the plot and the canvas edge, in points")
+	fs.StringVar(&o.Clamp, "clamp", "", "clamp values to MIN:MAX for plotting (e.g. -10:10), marking off-scale points")
+	fs.StringVar(&o.YRange, "y-range", "life", "y-axis range: life (at least -10..10), auto (fit the data), or MIN:MAX")
+	fs.BoolVar(&o.YTicks, "y-ticks", false, "show the y-axis with tick marks and values")
+	fs.StringVar(&o.YLabel, "y-label", "", "y-axis title, shown with -y-ticks (e.g. \"km run\")")
+	fs.BoolVar(&o.ShowValues, "show-values", false, "print each point's value next to its marker")
+	fs.StringVar(&o.ValueFormat, "value-format", "%.1f", "fmt verb used by -show-values")
+	fs.StringVar(&o.UncertaintyCol, "uncertainty-col", "", "column (header name or 1-based number) holding a symmetric ± uncertainty to draw as error bars")
+	fs.BoolVar(&o.SpanLane, "span-lane", false, "draw span rows (start-end) as bars in a strip below the chart")
+	fs.StringVar(&o.Chapters, "chapters", "", "CSV of life chapters (startYear,name) drawn as labeled bands")
+	fs.BoolVar(&o.NoAdjust, "no-adjust", false, "skip x-axis adjustment so the axis stays linear in time")
+	fs.BoolVar(&o.River, "river", false, "vary the line width with event density instead of relying on axis stretching")
+	fs.BoolVar(&o.Stems, "stems", false, "classic timeline layout: events on the zero line with labels on alternating stems")
+	fs.IntVar(&o.LabelLanes, "label-lanes", 0, "place labels in N non-overlapping lanes above and below the plot, with leader lines")
+	fs.StringVar(&o.LineDash, "line-dash", "solid", "connecting line dash pattern: solid, dashed, dotted, dashdot, or lengths like 4,3")
+	fs.Float64Var(&o.LineWidth, "line-width", 1.5, "connecting line width in points")
+	fs.BoolVar(&o.ShadeSign, "shade-sign", false, "shade the area above zero green and below zero red")
+	fs.StringVar(&o.ShadePositive, "shade-positive", "", "-shade-sign color above zero (default from -palette)")
+	fs.StringVar(&o.ShadeNegative, "shade-negative", "", "-shade-sign color below zero (default from -palette)")
+	fs.Float64Var(&o.ShadeOpacity, "shade-opacity", 0.18, "-shade-sign fill opacity between 0 and 1")
+	fs.StringVar(&o.PhotoCol, "photo-col", "", "column (header name or number) with image paths drawn as circular photo markers")
+	fs.Float64Var(&o.PhotoSize, "photo-size", 24, "photo marker diameter in points")
+	fs.StringVar(&o.ValueLabel, "value-label", "", "caption along the left edge saying what the values mean (e.g. \"Energy\")")
+	fs.BoolVar(&o.ValueArrows, "value-arrows", false, "with -value-label, add arrows pointing up and down along the caption")
+	fs.Float64Var(&o.Width, "width", 0, "canvas width in inches (default 12, or computed by -auto-size)")
+	fs.Float64Var(&o.Height, "height", 0, "canvas height in inches (default 8)")
+	fs.BoolVar(&o.AutoSize, "auto-size", false, "widen the canvas to give each labeled point about 60pt of horizontal room")
+	fs.Float64Var(&o.MaxWidth, "max-width", 48, "upper bound in inches for -auto-size")
+	fs.Float64Var(&o.LabelAngle, "label-angle", 0, "rotate labels by this many degrees, angled away from their marker")
+	fs.StringVar(&o.Adjust, "adjust", "spacing", "x-axis adjustment: spacing (keep points -min-spacing apart) or legacy (year-window density scaling)")
+	fs.Float64Var(&o.MaxCompression, "max-compression", 0.4, "smallest fraction of its proportional width a gap between events may be squeezed to by the adjustment (0 to 1)")
+	fs.Float64Var(&o.DensityWindow, "density-window", 0, "years around each event counted as its neighbourhood for density (0: 3 years, or 2% of the span on long timelines)")
+	fs.StringVar(&o.MinSpacing, "min-spacing", "18pt", "with -adjust spacing, the smallest on-screen gap between neighbouring points")
+	fs.StringVar(&o.Palette, "palette", "default", "colors used when none is given explicitly: default or colorblind (\"list\" prints all)")
+	fs.BoolVar(&o.Grayscale, "grayscale", false, "render in shades of grey with a darker, heavier line for black-and-white printing")
+	fs.StringVar(&o.Now, "now", "", "decimal year dividing lived from planned events; later events are drawn faded and dashed (default today)")
+	fs.Float64Var(&o.GapYears, "gap-years", 0, "draw the line between events more than N years apart as a gap (0 disables)")
+	fs.StringVar(&o.GapStyle, "gap-style", "dotted", "how -gap-years gaps are drawn: dotted, or break for an ellipsis mark")
+	fs.StringVar(&o.CategoryCol, "category-col", "category", "column (header name or number) grouping events into categories with their own marker color and shape")
+	fs.StringVar(&o.CategoryShapes, "category-shapes", "on", "give each category its own marker shape as well as color: on or off")
+	fs.BoolVar(&o.AnnotateGaps, "annotate-gaps", false, "caption long stretches without events along the zero axis, e.g. \"· 6 quiet years ·\"")
+	fs.Float64Var(&o.QuietYears, "quiet-years", 5, "with -annotate-gaps, the shortest gap in years that gets a caption")
+	fs.BoolVar(&o.MeanLine, "mean-line", false, "draw a dashed line at the average value, captioned \"avg N\"")
+	fs.BoolVar(&o.Cumulative, "cumulative", false, "overlay the running sum of values as a faint line on its own scale")
+	fs.BoolVar(&o.Slopes, "slopes", false, "caption steep segments with their change, e.g. \"+4 in 2y\", and an up or down arrow")
+	fs.Float64Var(&o.SlopeThreshold, "slope-threshold", 3, "with -slopes, the smallest change in value that gets a caption")
+	fs.StringVar(&o.Band, "band", "", "comma-separated CSV files or globs of a group; draws their median and 25-75th percentile band behind the input's line")
+	fs.IntVar(&o.BandMin, "band-min", 3, "with -band, the fewest series a year needs to be part of the band")
+	fs.BoolVar(&o.AutoAbbreviate, "auto-abbreviate", false, "shorten labels in crowded stretches, logging the full text")
+	fs.Float64Var(&o.AbbreviateDensity, "abbreviate-density", 5, "with -auto-abbreviate, events within 3 years of a point that make it crowded")
+	fs.IntVar(&o.AbbreviateLength, "abbreviate-length", 20, "with -auto-abbreviate, the longest label kept in crowded stretches")
+	fs.StringVar(&o.DefaultLabelFormat, "default-label-format", "", "Go template for rows without a label, using {{.Year}}, {{.Value}}, {{.Date}} and {{.Sign}} (e.g. '{{.Year}} ({{printf \"%+g\" .Value}})')")
+	fs.StringVar(&o.LineColor, "line-color", "", "connecting line color as hex or name (default from -palette)")
+	fs.StringVar(&o.MarkerColor, "marker-color", "", "marker color as hex or name (default from -palette)")
+	fs.StringVar(&o.AxisColor, "axis-color", "#c8c8c8", "zero axis line color as hex or name")
+	fs.Float64Var(&o.TickSize, "tick-size", 10, "with -years, x-axis tick label font size in points")
+	fs.Var((*stringList)(&o.Callouts), "callout", "boxed note with an arrow to the point nearest a year, as YEAR:text (repeatable)")
+	fs.Float64Var(&o.Project, "project", 0, "extend the linear trend of the values, dashed and faded, to this year")
+	fs.StringVar(&o.Aggregate, "aggregate", "", "collapse rows sharing a year or month into one point: year:mean, year:median, year:min, year:max, or month:...")
+	fs.StringVar(&o.SmoothData, "smooth-data", "", "replace values with a moving median over N points (median:N), keeping the originals as faint dots")
+	fs.Var((*stringList)(&o.Normalize), "normalize", "map values linearly onto another range at load time, e.g. \"from=0:100 to=-10:10 out=clamp\"; add file=NAME to map only that input or -band file (repeatable)")
+	fs.BoolVar(&o.NoOutlierCheck, "no-outlier-check", false, "skip the warning for values far from the median")
+	fs.Float64Var(&o.OutlierK, "outlier-k", 10, "warn about values more than this many median absolute deviations from the median")
+	fs.BoolVar(&o.DropOutliers, "drop-outliers", false, "leave flagged outliers out of the chart instead of only warning")
+	fs.StringVar(&o.PolarityStyle, "polarity-style", "off", "marker shapes by polarity: off, or shape (filled good, hollow bad, cross neutral)")
+	fs.StringVar(&o.SameYearOrder, "same-year-order", "input", "left-to-right order of events sharing a year: input, value or label")
+	f.failOnOverlap = fs.Bool("fail-on-overlap", false, "exit with status 3 after writing the chart if any labels overlap")
+	fs.BoolVar(&o.Interpolate, "interpolate", false, "fill missing whole years with interpolated points, drawn as small hollow markers without labels")
+	fs.BoolVar(&o.FillZero, "fill-zero", false, "add an unlabeled zero point for every whole year without data (for event counts)")
+	fs.BoolVar(&o.NoDupCheck, "no-dup-check", false, "skip the warning for same-year events with similar values and labels")
+	fs.BoolVar(&o.InvertValues, "invert-values", false, "negate every value at load time, for measures where high is bad")
+	fs.Var((*stringList)(&o.Invert), "invert", "negate the values of one input or -band file at load time, as -invert-values does for all (repeatable)")
+	fs.StringVar(&o.Baseline, "baseline", "", "reference CSV drawn as a grey dashed line behind the data, using the same x mapping")
+	fs.BoolVar(&o.BaselineShade, "baseline-shade", false, "with -baseline, shade between the line and the baseline (palette positive above, negative below)")
+	fs.IntVar(&o.Bucket, "bucket", 0, "group events into N-year bins, plotted at the bin centre with the mean value and sized by event count")
+	fs.StringVar(&o.BucketLabel, "bucket-label", "range", "with -bucket, label bins with their years (range) or their most important event (top)")
+	fs.BoolVar(&o.BucketRaw, "bucket-raw", false, "with -bucket, show the individual events as a faint scatter")
+	fs.StringVar(&o.ImportanceCol, "importance-col", "importance", "column (header name or 1-based number) holding each event's importance")
+	fs.BoolVar(&o.DensityWeighted, "density-weighted", false, "weight each event's contribution to density by its importance")
+	f.verbose = fs.Bool("verbose", false, "also print a note on each step and the step-by-step point adjustment log")
+	f.quiet = fs.Bool("quiet", false, "print nothing but errors")
+	f.force = fs.Bool("force", false, "overwrite output files that already exist")
+	f.backup = fs.Bool("backup", false, "rename output files that already exist to FILE.bak before writing")
+	f.version = fs.Bool("version", false, "print the version, commit and build date and exit")
+	f.dryRun = fs.Bool("dry-run", false, "do everything but write files: report warnings, overlaps and the summary, and exit as a real run would")
+	f.logJSON = fs.Bool("log-json", false, "report on stderr as newline-delimited JSON records: adjustments, warnings, errors and a final summary")
+	f.report = fs.String("report", "", "write how each point was positioned to a Markdown (.md) or JSON (.json) file")
+	fs.StringVar(&o.OrderRepair, "order-repair", "squeeze", "with -adjust legacy, how points scaled out of order are fixed: squeeze, push or fail")
+	f.splitDir = fs.String("split-by-category", "", "draw one chart per category, plus all.png with every event, into `DIR`; the output argument is not needed (implies -no-adjust)")
+	f.splitFormat = fs.String("split-format", "png", "with -split-by-category, the image format: png or svg")
+	f.splitTitle = fs.String("split-title", "{{.Title}} — {{.Category}}", "with -split-by-category, the title template for each category chart")
+	fs.BoolVar(&o.AllowFuture, "allow-future", false, "don't warn about events dated after -now plus -future-margin")
+	fs.Float64Var(&o.FutureMargin, "future-margin", 1, "years past -now before an event is reported as probably mistyped")
+	fs.Float64Var(&o.BirthYear, "birthyear", 0, "warn about events dated before this year")
+	fs.BoolVar(&o.FractionalMonths, "fractional-as-month", false, "show fractional years such as 2018.5 as months (\"Jul 2018\") in generated labels and the log")
+	fs.BoolVar(&o.AutoScale, "auto-scale", false, "when every value is between 0 and 100, map 0:100 onto -10:10 so 50 sits on the zero line")
+	fs.Float64Var(&o.Streaks, "streaks", 0, "shade runs of consecutive events all above or all below zero lasting at least this many years (0 = off)")
+	fs.BoolVar(&o.StreakLabels, "streak-labels", false, "with -streaks, caption each streak, e.g. \"3-year good streak\"")
+	f.open = fs.Bool("open", false, "open the chart in the default image viewer once it is written; with -watch, only after the first render")
+	f.watch = fs.Bool("watch", false, "render, then render again whenever the input, -chapters, -baseline, -band or config files change, until interrupted")
+	f.footer = fs.String("footer", "", "line of small text under the chart; takes the same placeholders as -title")
+	f.output = fs.String("output", "", "write the chart to `FILE`, as an alternative to giving it after the input")
+	f.cols = fs.Int("cols", 0, "with grid, the number of charts in a row (0 = as square as possible)")
+	f.names = fs.String("names", "", "with grid, comma-separated chart titles in input order; the default is each input's file name")
+	f.alignYears = fs.Bool("align-years", false, "with grid, give every chart the same years along the x-axis so lifetimes line up (implies -no-adjust)")
+	fs.StringVar(&o.LabelRule, "label-rule", "all", "which points get labels: all, every=N, abs>=X, or tagged")
+	fs.String("config", "", "read flag defaults from this YAML `FILE` instead of ./lifeline.yaml or ~/.config/lifeline/config.yaml")
+	return f
+}
+
+// run carries out the command line args, which start after the program
+// name, and returns the exit status with the error to report, if any.
+func run(args []string) (int, error) {
+	isGrid := false
+	if len(args) > 0 {
+		switch args[0] {
+		case "stats":
+			return runStats(args[1:])
+		case "lint":
+			return runLint(args[1:])
+		case "merge":
+			return runMerge(args[1:])
+		case "diff":
+			return runDiff(args[1:])
+		case "init":
+			return runInit(args[1:])
+		case "batch":
+			return runBatch(args[1:])
+		case "prompt":
+			return runPrompt(args[1:])
+		case "help":
+			return runHelp(args[1:])
+		case "render":
+			// The explicit form of the default command.
+			args = args[1:]
+		case "grid":
+			// A render of several inputs into one image.
+			isGrid, args = true, args[1:]
+		}
 	}
-	if len(rows) == 0 {
-		return nil, errors.New("empty CSV")
+	f := newRenderFlags()
+	fs, o := f.fs, f.chart
+
+	// `lifeline config show [flags]` prints the settings a render with
+	// the same flags would use, and where each came from.
+	// `lifeline completion SHELL` prints a completion script built from
+	// the flags just defined.
+	if len(args) > 0 && args[0] == "completion" {
+		if len(args) != 2 {
+			return exit.Usage, errors.New("completion takes the name of a shell")
+		}
+		if err := writeCompletion(os.Stdout, args[1], fs); err != nil {
+			return exit.Usage, err
+		}
+		return exit.OK, nil
 	}
 
-	var pts []Point
-	for i, row := range rows {
-		if len(row) < 2 {
-			return nil, fmt.Errorf("row %d: expected 2 or 3 columns, got %d", i+1, len(row))
+	cliArgs := args
+	showConfig := false
+	if len(cliArgs) > 0 && cliArgs[0] == "config" {
+		if len(cliArgs) < 2 || cliArgs[1] != "show" {
+			return exit.Usage, errors.New("config needs a subcommand")
 		}
-		yearStr := strings.TrimSpace(row[0])
-		valStr := strings.TrimSpace(row[1])
-
-		year, err := strconv.ParseFloat(yearStr, 64)
-		if err != nil {
-			return nil, fmt.Errorf("row %d: invalid year %q: %w", i+1, yearStr, err)
+		showConfig, cliArgs = true, cliArgs[2:]
+		// Named so -h describes config show rather than a render.
+		fs.Init("config", flag.ContinueOnError)
+	}
+	var files []string
+	if isGrid {
+		// Named so -h describes grid; the output comes last, after any
+		// flags among the inputs.
+		fs.Init("grid", flag.ContinueOnError)
+		var err error
+		if files, err = parseGridArgs(fs, cliArgs); err != nil {
+			return flagStatus(err)
 		}
+	} else {
+		cliArgs = expandAliases(fs, cliArgs)
+		if err := parseFlags(fs, cliArgs); err != nil {
+			return flagStatus(err)
+		}
+		files = fs.Args()
+	}
+	if *f.version {
+		b := currentBuild()
+		fmt.Printf("lifeline %s\ncommit:     %s\nbuilt:      %s\ngonum/plot: %s\n", b.Version, b.Commit, b.Date, b.Plot)
+		return exit.OK, nil
+	}
+	// Settings from the command line already count for warnings about the
+	// config file and environment.
+	logging.SetLevel(*f.quiet, *f.verbose, *f.logJSON)
+	sources, err := loadConfig(fs)
+	if err != nil {
+		return exit.Usage, err
+	}
+	if *f.quiet && *f.verbose {
+		return exit.Usage, errors.New("-quiet and -verbose can't be used together")
+	}
+	logging.SetLevel(*f.quiet, *f.verbose, *f.logJSON)
+	if showConfig {
+		writeConfig(os.Stdout, fs, sources)
+		return exit.OK, nil
+	}
 
-		val, err := strconv.ParseFloat(valStr, 64)
-		if err != nil {
-			return nil, fmt.Errorf("row %d: invalid value %q: %w", i+1, valStr, err)
+	if o.Palette == "list" {
+		for _, name := range render.PaletteNames() {
+			fmt.Printf("%-12s %s\n", name, render.Palettes[name].Description)
 		}
+		return exit.OK, nil
+	}
 
-		lbl := ""
-		if len(row) >= 3 {
-			lbl = strings.TrimSpace(row[2])
+	// Get positional arguments after flags
+	var gridInputs []string
+	if isGrid {
+		if *f.output != "" {
+			files = append(files, *f.output)
 		}
-		if lbl == "" {
-			lbl = fmt.Sprintf("%.0f, %.2f", year, val)
+		if len(files) < 2 {
+			return exit.Usage, errors.New("grid needs at least one input CSV and an output image")
 		}
-
-		pts = append(pts, Point{Year: year, Value: val, Label: lbl})
+		if *f.splitDir != "" || *f.watch {
+			return exit.Usage, errors.New("grid can't be used with -split-by-category or -watch")
+		}
+		gridInputs = files[:len(files)-1]
+		files = []string{gridInputs[0], files[len(files)-1]}
+	} else if *f.output != "" {
+		if len(files) != 1 {
+			return exit.Usage, errors.New("with -output, give only the input CSV")
+		}
+		files = append(files, *f.output)
+	}
+	if len(files) == 0 {
+		return exit.Usage, errors.New("need an input CSV")
+	}
+	if len(files) == 1 && *f.splitDir == "" {
+		if files[0] == "-" {
+			return exit.Usage, errors.New("reading standard input needs an output image")
+		}
+		files = append(files, defaultOutput(files[0]))
+		logging.Notef("No output given; writing %s\n", files[1])
 	}
-	return pts, nil
-}
 
-func main() {
-	// Define command-line flags
-	showYears := flag.Bool("years", false, "show years on x-axis")
-	title := flag.String("title", "My Life Line", "title for the timeline")
-	flag.Parse()
+	input := files[0]
+	output := ""
+	if len(files) > 1 {
+		output = files[1]
+	}
+	if *f.splitDir == "" {
+		if ext := strings.ToLower(filepath.Ext(output)); ext != ".png" && ext != ".svg" {
+			return exit.Usage, fmt.Errorf("unsupported output format %q (use .png or .svg)", ext)
+		}
+	} else {
+		// Each chart would be adjusted for its own events, putting the
+		// same year at a different x in each.
+		o.NoAdjust = true
+	}
+	if *f.watch && !watching {
+		return watchRender(args, watchedFiles(input, o.Chapters, o.Baseline, o.Band, fs.Lookup("config").Value.String()))
+	}
 
-	// Get positional arguments after flags
-	args := flag.Args()
-	if len(args) < 2 {
-		log.Fatalf("usage: %s [-years] [-title \"Custom Title\"] input.csv output.png\n", filepath.Base(os.Args[0]))
+	if err := o.Check(); err != nil {
+		return exit.Usage, err
+	}
+	if *f.splitFormat != "png" && *f.splitFormat != "svg" {
+		return exit.Usage, fmt.Errorf("invalid -split-format %q: use png or svg", *f.splitFormat)
+	}
+	splitTitleTmpl, err := parseSplitTitle(*f.splitTitle)
+	if err != nil {
+		return exit.Usage, fmt.Errorf("invalid -split-title: %v", err)
+	}
+	titleTmpl, err := parseTitleTemplate("title", *f.title)
+	if err != nil {
+		return exit.Usage, fmt.Errorf("invalid -title: %v", err)
+	}
+	footerTmpl, err := parseTitleTemplate("footer", *f.footer)
+	if err != nil {
+		return exit.Usage, fmt.Errorf("invalid -footer: %v", err)
+	}
+	var gridTitles []string
+	if isGrid {
+		if *f.cols < 0 {
+			return exit.Usage, fmt.Errorf("invalid -cols %d: must not be negative", *f.cols)
+		}
+		if gridTitles, err = gridNames(gridInputs, *f.names); err != nil {
+			return exit.Usage, fmt.Errorf("invalid -names: %v", err)
+		}
+		if *f.alignYears {
+			// Adjusted x positions of different inputs don't line up.
+			o.NoAdjust = true
+		}
 	}
 
-	input := args[0]
-	output := args[1]
+	inputs := []string{input}
+	if isGrid {
+		inputs = gridInputs
+	}
+	if err := o.CheckInputs(inputs); err != nil {
+		return exit.Usage, err
+	}
 
-	points, err := readCSV(input)
+	points, err := timeline.Load(input)
 	if err != nil {
-		log.Fatal(err)
+		return exit.Input, err
 	}
 
 	if len(points) == 0 {
-		log.Fatal("no data points")
+		return exit.Input, errors.New("no data points")
+	}
+	o.Categories = timeline.ReadCategories(points, strings.ToLower(o.CategoryCol))
+
+	// summary collects a summary of each chart for -log-json.
+	summary := runSummary{DryRun: *f.dryRun}
+	// titleFor fills in the -title and -footer placeholders for a chart of
+	// pts; every chart of a run shares one render date.
+	renderedAt := time.Now()
+	titleFor := func(t *template.Template, pts []timeline.Point) string {
+		return expandTitle(t, newTitleData(pts, input, o.FractionalMonths, renderedAt))
 	}
 
-	// Sort by year so the connecting line goes left->right in time.
-	sort.Slice(points, func(i, j int) bool { return points[i].Year < points[j].Year })
-
-	// Calculate density-based scaling for better spacing
-	adjustedPoints := make([]Point, len(points))
-	copy(adjustedPoints, points)
-
-	fmt.Printf("\n=== Point Adjustment Process ===\n")
-
-	// First pass: handle same-year overlaps with small offsets
-	for i := 0; i < len(adjustedPoints); i++ {
-		currentYear := adjustedPoints[i].Year
-		sameYearCount := 0
-
-		// Count how many events are in the same year (including current)
-		for j := 0; j < len(points); j++ {
-			if points[j].Year == currentYear {
-				sameYearCount++
+	// gridPanels collects the charts of a grid instead of saving them
+	// while collectPanels is set.
+	var gridPanels []render.Panel
+	collectPanels := false
+
+	// renderChart draws points to output. It returns the chart's axis
+	// ranges, before any span strip or label lanes are added, and the
+	// number of overlapping labels. When shared is set the chart uses
+	// those ranges instead of fitting its own.
+	renderChart := func(points []timeline.Point, output, chartTitle string, shared *render.Frame) (render.Frame, int, error) {
+		c, err := render.Draw(points, o, render.Spec{
+			Title:  chartTitle,
+			Footer: titleFor(footerTmpl, points),
+			Input:  input,
+			Output: output,
+			Shared: shared,
+		})
+		if err != nil {
+			return render.Frame{}, 0, err
+		}
+		if *f.report != "" && !*f.dryRun {
+			if err := adjust.WriteReport(*f.report, c.Adjustments); err != nil {
+				return render.Frame{}, 0, exit.RenderError(fmt.Errorf("-report: %w", err))
 			}
+			logging.Logf("Wrote adjustment report %s\n", *f.report)
 		}
 
-		// If there are multiple events in the same year, space them out
-		if sameYearCount > 1 {
-			eventIndex := 0
-			// Find which event this is among the same-year events
-			for j := 0; j < len(points); j++ {
-				if points[j].Year == currentYear {
-					if j == i {
-						break
-					}
-					eventIndex++
+		if collectPanels {
+			gridPanels = append(gridPanels, render.Panel{Plot: c.Plot, Footer: c.Footer, W: c.W, H: c.H})
+			logging.Notef("%s: %d points, %d adjusted\n", chartTitle, len(c.Adjustments), adjust.Moved(c.Adjustments))
+		} else {
+			save := savePlot
+			if *f.dryRun {
+				save = checkPlot
+			} else if *f.backup {
+				if err := backupFile(output); err != nil {
+					return render.Frame{}, 0, exit.RenderError(err)
 				}
 			}
-
-			// Add small decimal offset: -0.4, -0.2, 0.0, 0.2, 0.4, etc.
-			spacing := 0.2
-			totalOffset := float64(sameYearCount-1) * spacing / 2
-			newYear := currentYear - totalOffset + (float64(eventIndex) * spacing)
-			adjustedPoints[i].Year = newYear
-
-			// Log same-year adjustments
-			if newYear != currentYear {
-				fmt.Printf("Same-year adjustment: '%s' %.0f -> %.1f (event %d of %d in year %.0f)\n",
-					adjustedPoints[i].Label, currentYear, newYear, eventIndex+1, sameYearCount, currentYear)
+			if err := save(c.Plot, c.W, c.H, vg.Points(o.Margin), o.Grayscale, c.Footer, output); err != nil {
+				return render.Frame{}, 0, exit.RenderError(err)
 			}
+			logging.Notef("%s\n", adjustmentSummary(c.Adjustments, output, *f.dryRun))
 		}
+		summary.Charts = append(summary.Charts, newChartSummary(output, c.Points, c.Adjustments, len(c.Overlaps)))
+		return c.Frame, len(c.Overlaps), nil
 	}
 
-	// Second pass: apply density-based scaling for better distribution
-	densityScaledPoints := make([]Point, len(adjustedPoints))
-	copy(densityScaledPoints, adjustedPoints)
-
-	// Calculate local density for each point (within a 3-year window)
-	densityWindow := 3.0
-	densities := make([]float64, len(adjustedPoints))
-
-	for i := 0; i < len(adjustedPoints); i++ {
-		count := 0
-		for j := 0; j < len(adjustedPoints); j++ {
-			if math.Abs(adjustedPoints[j].Year-adjustedPoints[i].Year) <= densityWindow {
-				count++
+	if isGrid {
+		if err := checkOverwrite(output, *f.force, *f.backup); err != nil {
+			return exit.Render, err
+		}
+		inputPoints := [][]timeline.Point{points}
+		for _, in := range gridInputs[1:] {
+			pts, err := timeline.Load(in)
+			if err != nil {
+				return exit.Input, err
 			}
+			if len(pts) == 0 {
+				return exit.Input, fmt.Errorf("%s: no data points", in)
+			}
+			inputPoints = append(inputPoints, pts)
 		}
-		densities[i] = float64(count)
-	}
-
-	// Apply cumulative scaling based on density with normalization
-	if len(densityScaledPoints) > 0 {
-		minYear := adjustedPoints[0].Year
-		maxYear := adjustedPoints[len(adjustedPoints)-1].Year
-		totalRange := maxYear - minYear
-
-		// First, calculate all scaled distances
-		scaledDistances := make([]float64, len(adjustedPoints))
-		totalScaledDistance := 0.0
-
-		for i := 1; i < len(adjustedPoints); i++ {
-			// Distance to previous point
-			actualDistance := adjustedPoints[i].Year - adjustedPoints[i-1].Year
-
-			// Scale factor based on average density of the two points
-			avgDensity := (densities[i] + densities[i-1]) / 2
-			scaleFactor := 1.0 + (avgDensity-1.0)*1.5 // Amplify dense areas by up to 150%
-
-			scaledDistances[i] = actualDistance * scaleFactor
-			totalScaledDistance += scaledDistances[i]
+		// Every chart gives a category the same color.
+		var everyPoint []timeline.Point
+		for _, pts := range inputPoints[1:] {
+			timeline.ReadCategories(pts, strings.ToLower(o.CategoryCol))
+			everyPoint = append(everyPoint, pts...)
 		}
-
-		// Now normalize and apply positions within the original year range
-		densityScaledPoints[0].Year = minYear // Keep first point fixed
-		cumulativeScaledDistance := 0.0
-
-		for i := 1; i < len(adjustedPoints); i++ {
-			cumulativeScaledDistance += scaledDistances[i]
-
-			// Normalize to fit within original range
-			if totalScaledDistance > 0 {
-				normalizedPosition := cumulativeScaledDistance / totalScaledDistance
-				densityScaledPoints[i].Year = minYear + normalizedPosition*totalRange
-			} else {
-				densityScaledPoints[i].Year = adjustedPoints[i].Year
+		o.Categories = timeline.ReadCategories(append(everyPoint, points...), strings.ToLower(o.CategoryCol))
+
+		// A quiet first pass finds each chart's own frame, and a second
+		// draws them all with the shared ranges.
+		collectPanels = true
+		drawPanels := func(frames []render.Frame) ([]render.Frame, int, error) {
+			gridPanels, summary.Charts = nil, nil
+			var out []render.Frame
+			overlaps := 0
+			for i, pts := range inputPoints {
+				input = gridInputs[i]
+				var shared *render.Frame
+				if frames != nil {
+					shared = &frames[i]
+				}
+				frame, n, err := renderChart(append([]timeline.Point(nil), pts...), output, gridTitles[i], shared)
+				if err != nil {
+					return nil, 0, fmt.Errorf("%s: %w", gridInputs[i], err)
+				}
+				out = append(out, frame)
+				overlaps += n
 			}
+			return out, overlaps, nil
 		}
-
-		// Print density scaling info
-		fmt.Printf("\n=== Density-Based Scaling Results ===\n")
-
-		// Ensure chronological order is maintained (fix any backwards movement)
-		for i := 1; i < len(densityScaledPoints); i++ {
-			if densityScaledPoints[i].Year <= densityScaledPoints[i-1].Year {
-				// If this point would be before or at the same time as the previous, adjust it
-				densityScaledPoints[i].Year = densityScaledPoints[i-1].Year + 0.1
-			}
+		restore := logging.Mute()
+		frames, _, err := drawPanels(nil)
+		restore()
+		if err != nil {
+			return exit.Code(err), err
+		}
+		_, overlaps, err := drawPanels(gridFrames(frames, *f.alignYears))
+		if err != nil {
+			return exit.Code(err), err
 		}
 
-		// Show detailed density scaling for all points
-		for i := 0; i < len(adjustedPoints); i++ {
-			beforeDensityYear := adjustedPoints[i].Year
-			afterDensityYear := densityScaledPoints[i].Year
-
-			if math.Abs(afterDensityYear-beforeDensityYear) > 0.1 {
-				fmt.Printf("Density scaling: '%s' | Original: %.1f -> After same-year: %.1f -> After density: %.1f | Density: %.0f\n",
-					points[i].Label, points[i].Year, beforeDensityYear, afterDensityYear, densities[i])
-			} else {
-				fmt.Printf("No density change: '%s' | Year: %.1f | Density: %.0f\n",
-					points[i].Label, afterDensityYear, densities[i])
+		cols := gridCols(len(gridPanels), *f.cols)
+		c, err := render.DrawGrid(gridPanels, cols, vg.Points(o.Margin), o.Grayscale, output)
+		if err != nil {
+			return exit.Render, err
+		}
+		verb := "wrote"
+		if *f.dryRun {
+			verb = "would write"
+			if _, err := c.WriteTo(io.Discard); err != nil {
+				return exit.Render, err
+			}
+		} else {
+			if *f.backup {
+				if err := backupFile(output); err != nil {
+					return exit.Render, err
+				}
+			}
+			if err := writeImage(c, output); err != nil {
+				return exit.Render, err
 			}
 		}
-
-		fmt.Printf("=== End Density Scaling ===\n")
+		rows := (len(gridPanels) + cols - 1) / cols
+		logging.Notef("%d charts in a %dx%d grid, %s %s\n", len(gridPanels), rows, cols, verb, output)
+		logSummary(summary)
+		if *f.open && !*f.dryRun {
+			openInViewer(output)
+		}
+		if *f.failOnOverlap && overlaps > 0 {
+			return exit.Render, nil
+		}
+		return exit.OK, nil
 	}
 
-	// Use density-scaled points as the final adjusted points
-	adjustedPoints = densityScaledPoints
-
-	// Build XY data and labels using adjusted points.
-	xy := make(plotter.XYs, len(adjustedPoints))
-	lbls := make(plotter.XYs, len(adjustedPoints))
-	labels := make([]string, len(adjustedPoints))
-	minYear := math.MaxFloat64
-	maxYear := -math.MaxFloat64
-	minY := 0.0
-	maxY := 0.0
-
-	for i, p := range adjustedPoints {
-		xy[i].X = p.Year
-		xy[i].Y = p.Value
-		lbls[i].X = p.Year
-		lbls[i].Y = p.Value
-		labels[i] = p.Label
-
-		if p.Year < minYear {
-			minYear = p.Year
+	if *f.splitDir == "" {
+		if err := checkOverwrite(output, *f.force, *f.backup); err != nil {
+			return exit.Render, err
 		}
-		if p.Year > maxYear {
-			maxYear = p.Year
+		_, overlaps, err := renderChart(points, output, titleFor(titleTmpl, points), nil)
+		if err != nil {
+			return exit.Code(err), err
 		}
-		if p.Value < minY {
-			minY = p.Value
+		logSummary(summary)
+		if *f.open && !*f.dryRun {
+			openInViewer(output)
 		}
-		if p.Value > maxY {
-			maxY = p.Value
+		if *f.failOnOverlap && overlaps > 0 {
+			return exit.Render, nil
 		}
+		return exit.OK, nil
 	}
 
-	// Pad ranges a touch.
-	yPad := 0.6
-	if maxY-minY < 4 { // ensure some vertical breathing room
-		yPad = 1.0
-	}
-
-	p := plot.New()
-	p.Title.Text = *title
-
-	// Configure x-axis based on flag
-	if *showYears {
-		p.X.Label.Text = "Year"
-	} else {
-		p.X.Label.Text = ""
-		// Hide x-axis tick labels
-		p.X.Tick.Label.Font.Size = 0
-		p.X.Tick.Length = 0
-	}
-
-	p.Y.Label.Text = ""
-
-	// Set x-axis range to start from the smallest year provided
-	xMin := math.Floor(minYear)
-	xMax := math.Ceil(maxYear)
-	p.X.Min = xMin
-	p.X.Max = xMax
-
-	// Ensure y shows both positive and negative; if your data is bounded -10..10 you can hardcode:
-	if minY > -10 {
-		minY = -10
+	// One chart per category, each framed like the combined chart so
+	// they can be compared side by side.
+	if !*f.dryRun {
+		if err := os.MkdirAll(*f.splitDir, 0o755); err != nil {
+			return exit.Render, err
+		}
 	}
-	if maxY < 10 {
-		maxY = 10
+	// Every file is checked before any is drawn, so a refused overwrite
+	// doesn't leave a half-updated directory.
+	var charted []string
+	for _, name := range o.Categories {
+		if len(pointsInCategory(points, name)) < 2 {
+			logging.Warnf("no chart for category %q: fewer than two events", name)
+			continue
+		}
+		charted = append(charted, name)
 	}
-	p.Y.Min = math.Floor(minY - yPad)
-	p.Y.Max = math.Ceil(maxY + yPad)
-
-	// Hide y-axis tick labels and marks
-	p.Y.Tick.Label.Font.Size = 0
-	p.Y.Tick.Length = 0
-
-	// Optional grid for readability.
-	grid := plotter.NewGrid()
-	grid.Horizontal.Color = color.Gray{Y: 230}
-	grid.Vertical.Color = color.Gray{Y: 245}
-	p.Add(grid)
-
-	// Line connecting points.
-	line, err := plotter.NewLine(xy)
-	if err != nil {
-		log.Fatal(err)
+	for _, name := range append([]string{""}, charted...) {
+		if err := checkOverwrite(splitOutput(*f.splitDir, name, *f.splitFormat), *f.force, *f.backup); err != nil {
+			return exit.Render, err
+		}
 	}
-	line.Width = vg.Points(1.5)
-	line.Color = color.RGBA{A: 255, R: 100, G: 150, B: 200} // Light blue
-	p.Add(line)
 
-	// Scatter points.
-	sc, err := plotter.NewScatter(xy)
+	// Rendering adjusts points in place (normalizing, clamping and so
+	// on), so each chart gets its own copy.
+	frame, overlaps, err := renderChart(append([]timeline.Point(nil), points...), splitOutput(*f.splitDir, "", *f.splitFormat), titleFor(titleTmpl, points), nil)
 	if err != nil {
-		log.Fatal(err)
+		return exit.Code(err), err
 	}
-	sc.Radius = vg.Points(3)
-	sc.GlyphStyle.Color = plotutil.Color(1)
-	p.Add(sc)
-
-	// Labels (captions) next to each point with alternating positions to avoid overlap.
-	for i, point := range adjustedPoints {
-		labelData := plotter.XYLabels{
-			XYs:    plotter.XYs{{X: point.Year, Y: point.Value}},
-			Labels: []string{point.Label},
-		}
-		l, err := plotter.NewLabels(labelData)
+	for _, name := range charted {
+		out := splitOutput(*f.splitDir, name, *f.splitFormat)
+		catPoints := pointsInCategory(points, name)
+		_, n, err := renderChart(catPoints, out, splitTitle(splitTitleTmpl, titleFor(titleTmpl, catPoints), name), &frame)
 		if err != nil {
-			log.Fatal(err)
-		}
-
-		// Alternate label positions: above/below and left/right to reduce overlap
-		xOffset := vg.Points(8)
-		yOffset := vg.Points(8)
-
-		// Alternate between top-right, bottom-right, top-left, bottom-left
-		switch i % 4 {
-		case 0: // top-right
-			l.Offset = vg.Point{X: xOffset, Y: yOffset}
-		case 1: // bottom-right
-			l.Offset = vg.Point{X: xOffset, Y: -yOffset}
-		case 2: // top-left
-			l.Offset = vg.Point{X: -xOffset, Y: yOffset}
-		case 3: // bottom-left
-			l.Offset = vg.Point{X: -xOffset, Y: -yOffset}
+			return exit.Code(err), err
 		}
-
-		// Make font smaller to reduce label size
-		l.TextStyle[0].Font.Size = vg.Points(9)
-
-		p.Add(l)
+		overlaps += n
 	}
-
-	// Draw custom x-axis along y=0:
-	originY := 0.0
-
-	// x-axis along y=0 across the full x range
-	xAxisXY := make(plotter.XYs, 2)
-	xAxisXY[0].X, xAxisXY[0].Y = p.X.Min, originY
-	xAxisXY[1].X, xAxisXY[1].Y = p.X.Max, originY
-	xAxisLine, _ := plotter.NewLine(xAxisXY)
-	xAxisLine.Color = color.RGBA{A: 255, R: 200, G: 200, B: 200} // Light grey
-	xAxisLine.Width = vg.Points(1.0)
-	p.Add(xAxisLine)
-
-	// Configure axis colors based on flag
-	if *showYears {
-		// Make the x-axis light grey when showing years
-		p.X.Color = color.RGBA{A: 255, R: 200, G: 200, B: 200} // Light grey
-	} else {
-		// Make the x-axis invisible when not showing years
-		p.X.Color = color.RGBA{A: 0, R: 0, G: 0, B: 0} // Invisible
+	logSummary(summary)
+	if *f.open && !*f.dryRun {
+		openInViewer(splitOutput(*f.splitDir, "", *f.splitFormat))
 	}
-	p.Y.Color = color.RGBA{A: 0, R: 0, G: 0, B: 0} // Make y-axis invisible
-
-	// Save output (PNG). Change to .svg if you prefer.
-	ext := strings.ToLower(filepath.Ext(output))
-	w, h := 12*vg.Inch, 8*vg.Inch // Larger size to accommodate labels
-	switch ext {
-	case ".png":
-		if err := p.Save(w, h, output); err != nil {
-			log.Fatal(err)
-		}
-	case ".svg":
-		if err := p.Save(w, h, output); err != nil {
-			log.Fatal(err)
-		}
-	default:
-		log.Fatalf("unsupported output format %q (use .png or .svg)", ext)
+	if *f.failOnOverlap && overlaps > 0 {
+		return exit.Render, nil
 	}
-
-	fmt.Printf("Wrote %s\n", output)
+	return exit.OK, nil
 }
@@ -0,0 +1,337 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/rojaswestall/lifeline/internal/render"
+	"github.com/rojaswestall/lifeline/internal/timeline"
+)
+
+// sample is a small timeline used by the tests that render.
+const sample = `1987,0,Born in Rosario
+2000,3,Joins the academy
+2004,5,Professional debut
+2006,7,First title
+2014,-4,Loses the final
+2022,10,Wins the World Cup
+`
+
+// inTempDir runs the test from a new directory with no config file in it
+// or in the home directory, and writes sample there as input.csv.
+func inTempDir(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	t.Setenv("HOME", t.TempDir())
+	t.Chdir(dir)
+	if err := os.WriteFile("input.csv", []byte(sample), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+func TestRenderFlagsParse(t *testing.T) {
+	f := newRenderFlags()
+	args := []string{"-t", "My life", "--years", "-adjust=legacy", "-callout", "2004:Debut", "-o", "out.svg", "input.csv"}
+	if err := parseFlags(f.fs, expandAliases(f.fs, args)); err != nil {
+		t.Fatal(err)
+	}
+	if *f.title != "My life" {
+		t.Errorf("title = %q, want %q", *f.title, "My life")
+	}
+	if *f.output != "out.svg" {
+		t.Errorf("output = %q, want %q", *f.output, "out.svg")
+	}
+	o := f.chart
+	if !o.ShowYears || o.Adjust != "legacy" || len(o.Callouts) != 1 || o.Callouts[0] != "2004:Debut" {
+		t.Errorf("chart options = years %v, adjust %q, callouts %q", o.ShowYears, o.Adjust, o.Callouts)
+	}
+	if got := f.fs.Args(); len(got) != 1 || got[0] != "input.csv" {
+		t.Errorf("args = %q, want [input.csv]", got)
+	}
+	if err := o.Check(); err != nil {
+		t.Errorf("Check: %v", err)
+	}
+}
+
+func TestDefaultOutput(t *testing.T) {
+	for _, tc := range []struct{ input, want string }{
+		{"life.csv", "life.png"},
+		{"life", "life.png"},
+		{"data.v2/life", "data.v2/life.png"},
+		{"data.v2/life.csv", "data.v2/life.png"},
+		{"life.2024.csv", "life.2024.png"},
+		{"chart.png", "chart.png"},
+		{".life", ".life.png"},
+		{"data/.life", "data/.life.png"},
+	} {
+		if got := defaultOutput(filepath.FromSlash(tc.input)); got != filepath.FromSlash(tc.want) {
+			t.Errorf("defaultOutput(%q) = %q, want %q", tc.input, got, tc.want)
+		}
+	}
+}
+
+func TestRunDefaultPath(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		args []string
+		want string
+	}{
+		{"input and output", []string{"-quiet", "input.csv", "chart.png"}, "chart.png"},
+		{"svg output", []string{"-quiet", "input.csv", "chart.svg"}, "chart.svg"},
+		{"default output", []string{"-quiet", "input.csv"}, "input.png"},
+		{"render command", []string{"render", "-quiet", "input.csv", "chart.png"}, "chart.png"},
+		{"output flag", []string{"-quiet", "-o", "chart.png", "input.csv"}, "chart.png"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := inTempDir(t)
+			code, err := run(tc.args)
+			if err != nil || code != 0 {
+				t.Fatalf("run(%q) = %d, %v", tc.args, code, err)
+			}
+			fi, err := os.Stat(filepath.Join(dir, tc.want))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if fi.Size() == 0 {
+				t.Errorf("%s is empty", tc.want)
+			}
+		})
+	}
+}
+
+func TestSameYearDeterministic(t *testing.T) {
+	inTempDir(t)
+	const csv = "2001,2,Moves out\n2005,4,Starts a band\n2005,-3,Breaks an arm\n2005,6,Meets Sam\n2009,1,Graduates\n"
+	if err := os.WriteFile("same.csv", []byte(csv), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	var first []byte
+	for i := range 5 {
+		if code, err := run([]string{"-quiet", "-force", "same.csv", "same.svg"}); err != nil || code != 0 {
+			t.Fatalf("run = %d, %v", code, err)
+		}
+		svg, err := os.ReadFile("same.svg")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if i == 0 {
+			for _, label := range []string{"Starts a band", "Breaks an arm", "Meets Sam"} {
+				if !bytes.Contains(svg, []byte(label)) {
+					t.Errorf("no %q in the chart", label)
+				}
+			}
+			first = svg
+		} else if !bytes.Equal(svg, first) {
+			t.Fatalf("render %d differs from the first", i+1)
+		}
+	}
+}
+
+// svgText matches the text elements of an SVG chart.
+var svgText = regexp.MustCompile(`(?s)<text[^>]*>(.*?)</text>`)
+
+func TestLabelsShowOriginalYears(t *testing.T) {
+	inTempDir(t)
+	// Ties, a cluster and long gaps, without labels, so every caption is
+	// generated from its year.
+	years := []string{"1980", "1991", "1995", "1995", "1995", "1996", "1996.5", "1997", "1997", "1998", "2004", "2005", "2005", "2022"}
+	var csv strings.Builder
+	for i, y := range years {
+		fmt.Fprintf(&csv, "%s,%d\n", y, i%7-3)
+	}
+	if err := os.WriteFile("cluster.csv", []byte(csv.String()), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	original := make(map[string]bool)
+	for _, y := range years {
+		original[strings.TrimSuffix(y, ".5")] = true
+	}
+	year := regexp.MustCompile(`\b\d{4}(\.\d+)?\b`)
+	for _, mode := range []string{"spacing", "legacy"} {
+		if code, err := run([]string{"-quiet", "-force", "-adjust", mode, "cluster.csv", "cluster.svg"}); err != nil || code != 0 {
+			t.Fatalf("run -adjust %s = %d, %v", mode, code, err)
+		}
+		svg, err := os.ReadFile("cluster.svg")
+		if err != nil {
+			t.Fatal(err)
+		}
+		labels := 0
+		for _, m := range svgText.FindAllSubmatch(svg, -1) {
+			for _, y := range year.FindAllString(string(m[1]), -1) {
+				labels++
+				if !original[y] {
+					t.Errorf("-adjust %s: label %q shows %s, which is not a year in the data", mode, m[1], y)
+				}
+			}
+		}
+		if labels != len(years) {
+			t.Errorf("-adjust %s: found %d years in labels, want %d", mode, labels, len(years))
+		}
+	}
+}
+
+// drawWith draws pts with the render defaults changed by args, as a
+// render of in.csv would.
+func drawWith(t *testing.T, pts []timeline.Point, args ...string) *render.Chart {
+	t.Helper()
+	f := newRenderFlags()
+	if err := parseFlags(f.fs, args); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.chart.Check(); err != nil {
+		t.Fatal(err)
+	}
+	c, err := render.Draw(pts, f.chart, render.Spec{Title: "test", Input: "in.csv", Output: "out.png"})
+	if err != nil {
+		t.Fatalf("Draw %q: %v", args, err)
+	}
+	return c
+}
+
+func TestDrawFewPoints(t *testing.T) {
+	finite := func(vs ...float64) bool {
+		for _, v := range vs {
+			if math.IsNaN(v) || math.IsInf(v, 0) {
+				return false
+			}
+		}
+		return true
+	}
+	for _, csv := range []string{"2010,4,Alone\n", "2010,4,First\n2014,-2,Second\n", "2010,4,First\n2010,-2,Second\n"} {
+		for _, args := range [][]string{nil, {"-adjust", "legacy"}, {"-years", "-y-range", "auto"}, {"-no-adjust"}} {
+			pts, err := timeline.ReadCSV(writeTemp(t, csv))
+			if err != nil {
+				t.Fatal(err)
+			}
+			c := drawWith(t, pts, args...)
+			fr := c.Frame
+			name := fmt.Sprintf("%d point(s) %q", len(pts), args)
+			if !finite(fr.XMin, fr.XMax, fr.YMin, fr.YMax) || fr.XMin >= fr.XMax || fr.YMin >= fr.YMax {
+				t.Errorf("%s: frame %+v", name, fr)
+			}
+			for _, p := range c.Points {
+				if !finite(p.PlotX) || p.PlotX < fr.XMin || p.PlotX > fr.XMax {
+					t.Errorf("%s: %q at x=%g, outside %g to %g", name, p.Label, p.PlotX, fr.XMin, fr.XMax)
+				}
+			}
+			if len(pts) == 1 && (fr.XMin != 2009 || fr.XMax != 2011) {
+				t.Errorf("%s: x-range %g to %g, want 2009 to 2011", name, fr.XMin, fr.XMax)
+			}
+		}
+	}
+}
+
+// writeTemp writes data to a new file and returns its name.
+func writeTemp(t *testing.T, data string) string {
+	t.Helper()
+	name := filepath.Join(t.TempDir(), "in.csv")
+	if err := os.WriteFile(name, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return name
+}
+
+func TestInitSampleRenders(t *testing.T) {
+	inTempDir(t)
+	if code, err := run([]string{"init", "life.csv"}); err != nil || code != 0 {
+		t.Fatalf("init = %d, %v", code, err)
+	}
+	for _, tc := range []struct {
+		name, file string
+		events     int
+	}{
+		{"init", "life.csv", len(starterRows)},
+		{"born 1985", writeTemp(t, starterCSV("life.csv", 1985)), len(starterRows) + 1},
+	} {
+		pts, err := timeline.ReadCSV(tc.file)
+		if err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+		if len(pts) != tc.events {
+			t.Errorf("%s: read %d events, want %d", tc.name, len(pts), tc.events)
+		}
+		drawWith(t, pts)
+	}
+	if code, err := run([]string{"-quiet", "life.csv"}); err != nil || code != 0 {
+		t.Fatalf("render = %d, %v", code, err)
+	}
+}
+
+// outputOf returns what f writes to out, which is os.Stdout or os.Stderr.
+func outputOf(t *testing.T, out **os.File, f func()) string {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	saved := *out
+	*out = w
+	defer func() { *out = saved }()
+	done := make(chan []byte)
+	go func() {
+		b, _ := io.ReadAll(r)
+		done <- b
+	}()
+	f()
+	w.Close()
+	return string(<-done)
+}
+
+func TestAutoSizePrintsCanvas(t *testing.T) {
+	inTempDir(t)
+	out := outputOf(t, &os.Stderr, func() {
+		if code, err := run([]string{"-auto-size", "input.csv"}); err != nil || code != 0 {
+			t.Errorf("run = %d, %v", code, err)
+		}
+	})
+	if !strings.Contains(out, "Canvas size: 12.0in x 8.0in\n") {
+		t.Errorf("no canvas size in the output:\n%s", out)
+	}
+}
+
+func TestMeanLineIgnoresSmoothing(t *testing.T) {
+	inTempDir(t)
+	// Smoothing flattens the spike, so the smoothed values average 0.
+	const csv = "2001,0,A\n2002,0,B\n2003,10,Spike\n2004,0,C\n2005,0,D\n"
+	if err := os.WriteFile("spike.csv", []byte(csv), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if code, err := run([]string{"-quiet", "-smooth-data", "median:3", "-mean-line", "spike.csv", "spike.svg"}); err != nil || code != 0 {
+		t.Fatalf("run = %d, %v", code, err)
+	}
+	svg, err := os.ReadFile("spike.svg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var captions []string
+	for _, m := range svgText.FindAllSubmatch(svg, -1) {
+		if s := string(m[1]); strings.HasPrefix(s, "avg ") {
+			captions = append(captions, s)
+		}
+	}
+	if len(captions) != 1 || captions[0] != "avg 2.0" {
+		t.Errorf("mean line captions %q, want [avg 2.0]", captions)
+	}
+}
+
+func TestValueMappingsPrinted(t *testing.T) {
+	inTempDir(t)
+	out := outputOf(t, &os.Stderr, func() {
+		if code, err := run([]string{"-normalize", "from=-20:20", "-invert", "input.csv", "input.csv", "out.png"}); err != nil || code != 0 {
+			t.Errorf("run = %d, %v", code, err)
+		}
+	})
+	for _, want := range []string{"Normalized values of input.csv ", "Inverted values of input.csv\n"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("no %q in the output:\n%s", want, out)
+		}
+	}
+}
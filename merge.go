@@ -0,0 +1,234 @@
+package main
+
+import (
+	"encoding/csv"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/rojaswestall/lifeline/internal/exit"
+	"github.com/rojaswestall/lifeline/internal/logging"
+	"github.com/rojaswestall/lifeline/internal/timeline"
+)
+
+// mergeRow is one data row carried through the merge subcommand: the
+// parsed point for sorting and duplicate checks, and the trimmed cells to
+// write back out, keyed by output column name.
+type mergeRow struct {
+	Point  timeline.Point
+	Cells  map[string]string
+	Source string // "file:row", for messages
+}
+
+// key identifies exact duplicates: same year or span, value and label.
+// The category is left out so the same row filed under two life areas is
+// still caught.
+func (r mergeRow) key() string {
+	return fmt.Sprintf("%g|%g|%g|%s", r.Point.Year, r.Point.End, r.Point.Value, r.Cells["label"])
+}
+
+// mergeColumns are written first, in this order; other columns follow in
+// the order they were first seen.
+var mergeColumns = []string{"year", "value", "label", "category"}
+
+// readMergeFile reads a lifeline CSV for merging, checking every row with
+// timeline.ParseRow. Columns after the label are named by the header, or
+// "colN" in files without one. When category is set, rows with an empty
+// category column get it. The returned column names exclude mergeColumns.
+func readMergeFile(path, category string) ([]mergeRow, []string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer f.Close()
+	rows, lines, err := timeline.ReadRecords(f)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if len(rows) == 0 {
+		return nil, nil, fmt.Errorf("%s: empty CSV", path)
+	}
+
+	header := timeline.CSVHeader(rows[0])
+	first := 0
+	if header != nil {
+		first = 1
+	}
+	var extra []string
+	seen := make(map[string]bool)
+	var out []mergeRow
+	for i := first; i < len(rows); i++ {
+		p, err := timeline.ParseRow(rows[i], header, lines[i])
+		if err != nil {
+			return nil, nil, fmt.Errorf("%s: row %d: %w", path, lines[i], err)
+		}
+		cells := make(map[string]string)
+		for c, v := range rows[i] {
+			name := "col" + strconv.Itoa(c+1)
+			switch {
+			case c < 3:
+				name = mergeColumns[c]
+			case c < len(header) && header[c] != "":
+				name = header[c]
+			}
+			cells[name] = strings.TrimSpace(v)
+			if c >= 3 && name != "category" && !seen[name] {
+				seen[name] = true
+				extra = append(extra, name)
+			}
+		}
+		if cells["category"] == "" {
+			cells["category"] = category
+		}
+		out = append(out, mergeRow{Point: p, Cells: cells, Source: fmt.Sprintf("%s:%d", path, lines[i])})
+	}
+	return out, extra, nil
+}
+
+// categoryFromPath names the category for a merged file after its base
+// name, so work.csv becomes "work".
+func categoryFromPath(path string) string {
+	base := filepath.Base(path)
+	return strings.TrimSuffix(base, filepath.Ext(base))
+}
+
+// writeMergedCSV writes rows under a header of cols.
+func writeMergedCSV(path string, cols []string, rows []mergeRow) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	w := csv.NewWriter(f)
+	w.Write(cols)
+	for _, r := range rows {
+		rec := make([]string, len(cols))
+		for i, c := range cols {
+			rec[i] = r.Cells[c]
+		}
+		w.Write(rec)
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// parseInterspersed parses args with parseFlags, allowing flags after
+// the file arguments as well as before them, and returns the file
+// arguments.
+func parseInterspersed(fs *flag.FlagSet, args []string) ([]string, error) {
+	var files []string
+	for {
+		if err := parseFlags(fs, args); err != nil {
+			return nil, err
+		}
+		if fs.NArg() == 0 {
+			return files, nil
+		}
+		files = append(files, fs.Arg(0))
+		args = fs.Args()[1:]
+	}
+}
+
+// mergeFlags are the flags of the merge subcommand.
+type mergeFlags struct {
+	fs      *flag.FlagSet
+	outPath *string
+	update  *bool
+}
+
+// newMergeFlags defines the merge flags on a new FlagSet.
+func newMergeFlags() mergeFlags {
+	fs := flag.NewFlagSet("merge", flag.ContinueOnError)
+	return mergeFlags{
+		fs:      fs,
+		outPath: fs.String("o", "", "write the merged CSV to `FILE`"),
+		update:  fs.Bool("update", false, "merge into the existing -o file, keeping its rows as they are and adding only rows it lacks"),
+	}
+}
+
+// runMerge implements `lifeline merge [-update] -o out.csv a.csv b.csv ...`.
+func runMerge(args []string) (int, error) {
+	f := newMergeFlags()
+	fs := f.fs
+	inputs, err := parseInterspersed(fs, args)
+	if err != nil {
+		return flagStatus(err)
+	}
+	if *f.outPath == "" || len(inputs) == 0 {
+		return exit.Usage, errors.New("merge needs -o and at least one input file")
+	}
+
+	var rows []mergeRow
+	cols := append([]string(nil), mergeColumns...)
+	addCols := func(extra []string) {
+		for _, c := range extra {
+			found := false
+			for _, have := range cols {
+				found = found || have == c
+			}
+			if !found {
+				cols = append(cols, c)
+			}
+		}
+	}
+
+	present := make(map[string]bool)
+	if _, err := os.Stat(*f.outPath); err == nil {
+		if !*f.update {
+			return exit.Render, fmt.Errorf("%s already exists; use -update to merge into it", *f.outPath)
+		}
+		existing, extra, err := readMergeFile(*f.outPath, "")
+		if err != nil {
+			return exit.Input, err
+		}
+		for _, r := range existing {
+			present[r.key()] = true
+		}
+		rows = append(rows, existing...)
+		addCols(extra)
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return exit.Render, err
+	}
+	kept := len(rows)
+
+	skipped := 0
+	first := make(map[string]string)
+	for _, path := range inputs {
+		in, extra, err := readMergeFile(path, categoryFromPath(path))
+		if err != nil {
+			return exit.Input, err
+		}
+		addCols(extra)
+		for _, r := range in {
+			k := r.key()
+			if present[k] {
+				skipped++
+				continue
+			}
+			if src, ok := first[k]; ok {
+				logging.Warnf("%s repeats %s (%s, %s, %q)", r.Source, src, r.Cells["year"], r.Cells["value"], r.Cells["label"])
+			} else {
+				first[k] = r.Source
+			}
+			rows = append(rows, r)
+		}
+	}
+
+	sort.SliceStable(rows, func(i, j int) bool { return rows[i].Point.Year < rows[j].Point.Year })
+	if err := writeMergedCSV(*f.outPath, cols, rows); err != nil {
+		return exit.Render, err
+	}
+	if *f.update {
+		fmt.Printf("Kept %d existing rows, added %d, skipped %d already present\n", kept, len(rows)-kept, skipped)
+	}
+	fmt.Printf("Wrote %s (%d rows from %d file(s))\n", *f.outPath, len(rows), len(inputs))
+	return exit.OK, nil
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"os/exec"
+	"runtime"
+
+	"github.com/rojaswestall/lifeline/internal/logging"
+)
+
+// viewerStarted is set once -open has started a viewer, so a -watch only
+// opens the chart after its first render and not after every change.
+var viewerStarted bool
+
+// viewerCommand opens path with the platform's default application.
+func viewerCommand(path string) *exec.Cmd {
+	switch runtime.GOOS {
+	case "darwin":
+		return exec.Command("open", path)
+	case "windows":
+		// The empty argument is the window title start expects before a
+		// quoted path.
+		return exec.Command("cmd", "/c", "start", "", path)
+	default:
+		return exec.Command("xdg-open", path)
+	}
+}
+
+// openInViewer starts a viewer on path without waiting for it. Failing to
+// start one is only a warning: the chart has been written either way.
+func openInViewer(path string) {
+	if viewerStarted {
+		return
+	}
+	viewerStarted = true
+	cmd := viewerCommand(path)
+	if err := cmd.Start(); err != nil {
+		logging.Warnf("-open: couldn't open %s: %v", path, err)
+		return
+	}
+	logging.Logf("opened %s with %s\n", path, cmd.Path)
+	go cmd.Wait()
+}
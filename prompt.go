@@ -0,0 +1,313 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/rojaswestall/lifeline/internal/exit"
+	"github.com/rojaswestall/lifeline/internal/timeline"
+)
+
+// errAbandoned is returned by a prompter on Ctrl-C or the end of input.
+var errAbandoned = errors.New("abandoned")
+
+// prompter asks questions on out and reads the answers, one per line,
+// from in, giving up with errAbandoned once ctx is done.
+type prompter struct {
+	ctx   context.Context
+	out   io.Writer
+	lines <-chan string
+}
+
+// newPrompter starts reading lines from in. The reader goroutine is left
+// behind if ctx ends while it is blocked, which is fine as the program is
+// about to exit.
+func newPrompter(ctx context.Context, in io.Reader, out io.Writer) *prompter {
+	lines := make(chan string)
+	go func() {
+		defer close(lines)
+		sc := bufio.NewScanner(in)
+		for sc.Scan() {
+			lines <- sc.Text()
+		}
+	}()
+	return &prompter{ctx: ctx, out: out, lines: lines}
+}
+
+// ask prints question and returns the trimmed answer, asking again for as
+// long as check rejects it.
+func (p *prompter) ask(question string, check func(string) error) (string, error) {
+	for {
+		fmt.Fprint(p.out, question, " ")
+		var line string
+		var ok bool
+		select {
+		case <-p.ctx.Done():
+			return "", errAbandoned
+		case line, ok = <-p.lines:
+			if !ok {
+				return "", errAbandoned
+			}
+		}
+		s := strings.TrimSpace(line)
+		if check == nil {
+			return s, nil
+		}
+		if err := check(s); err != nil {
+			fmt.Fprintf(p.out, "  %v\n", err)
+			continue
+		}
+		return s, nil
+	}
+}
+
+// yesNo asks a yes/no question; an empty answer is def.
+func (p *prompter) yesNo(question string, def bool) (bool, error) {
+	hint := " [y/N]"
+	if def {
+		hint = " [Y/n]"
+	}
+	s, err := p.ask(question+hint, func(s string) error {
+		switch strings.ToLower(s) {
+		case "", "y", "yes", "n", "no":
+			return nil
+		}
+		return errors.New("please answer y or n")
+	})
+	if err != nil {
+		return false, err
+	}
+	if s == "" {
+		return def, nil
+	}
+	return strings.HasPrefix(strings.ToLower(s), "y"), nil
+}
+
+// checkYear accepts what the year column does: a year such as 2014 or
+// 2014.5, or a span such as 2010-2014.
+func checkYear(s string) error {
+	year, end, err := timeline.ParseYearSpan(s)
+	if err != nil {
+		return fmt.Errorf("%q is not a year or a span such as 2010-2014", s)
+	}
+	if math.IsNaN(year) || math.IsInf(year, 0) || math.IsNaN(end) || math.IsInf(end, 0) {
+		return fmt.Errorf("%q is not a finite year", s)
+	}
+	return nil
+}
+
+// checkValue returns a check for values between lo and hi.
+func checkValue(lo, hi float64) func(string) error {
+	return func(s string) error {
+		v, err := strconv.ParseFloat(s, 64)
+		if err != nil || v < lo || v > hi {
+			return fmt.Errorf("%q is not a number from %g to %g", s, lo, hi)
+		}
+		return nil
+	}
+}
+
+// eventColumns returns the header of the events file at path, or nil when
+// it has none, and whether the file exists.
+func eventColumns(path string) (header []string, exists bool, err error) {
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, false, nil
+	} else if err != nil {
+		return nil, false, err
+	}
+	defer f.Close()
+	rows, _, err := timeline.ReadRecords(f)
+	if err != nil {
+		return nil, true, err
+	}
+	if len(rows) > 0 {
+		header = timeline.CSVHeader(rows[0])
+	}
+	return header, true, nil
+}
+
+// appendEvent adds row to the end of the file at path, creating it with a
+// header when it doesn't exist. The file is replaced by renaming a
+// complete copy over it, so an interrupted write leaves it as it was.
+func appendEvent(path string, row []string) error {
+	data, err := os.ReadFile(path)
+	mode := os.FileMode(0o644)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		data = []byte("year,value,label\n")
+	case err != nil:
+		return err
+	default:
+		if fi, err := os.Stat(path); err == nil {
+			mode = fi.Mode().Perm()
+		}
+		if len(data) > 0 && data[len(data)-1] != '\n' {
+			data = append(data, '\n')
+		}
+	}
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	w.Write(row)
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return err
+	}
+	data = append(data, buf.Bytes()...)
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(mode); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// askEvent asks for one event and returns it as a row for a file with the
+// given header (nil for year,value,label).
+func askEvent(p *prompter, header []string, lo, hi float64) ([]string, error) {
+	year, err := p.ask("Year?", checkYear)
+	if err != nil {
+		return nil, err
+	}
+	value, err := p.ask(fmt.Sprintf("Value (%g..%g)?", lo, hi), checkValue(lo, hi))
+	if err != nil {
+		return nil, err
+	}
+	label, err := p.ask("What happened?", nil)
+	if err != nil {
+		return nil, err
+	}
+	row := []string{year, value, label}
+	for i := len(row); i < len(header); i++ {
+		row = append(row, "")
+		if header[i] == "category" {
+			if row[i], err = p.ask("Category (empty for none)?", nil); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return row, nil
+}
+
+// promptFlags are the flags of the prompt subcommand.
+type promptFlags struct {
+	fs        *flag.FlagSet
+	rangeFlag *string
+}
+
+// newPromptFlags defines the prompt flags on a new FlagSet.
+func newPromptFlags() promptFlags {
+	fs := flag.NewFlagSet("prompt", flag.ContinueOnError)
+	return promptFlags{
+		fs:        fs,
+		rangeFlag: fs.String("range", "-10:10", "accept values within `MIN:MAX`"),
+	}
+}
+
+// runPrompt implements `lifeline prompt [-range MIN:MAX] events.csv`: it
+// asks for events one at a time, appending each to the file as soon as it
+// is complete, and offers to draw the chart at the end.
+func runPrompt(args []string) (int, error) {
+	f := newPromptFlags()
+	files, err := parseInterspersed(f.fs, args)
+	if err != nil {
+		return flagStatus(err)
+	}
+	if len(files) != 1 {
+		return exit.Usage, errors.New("prompt takes one events file")
+	}
+	path := files[0]
+	lo, hi, err := timeline.ParseRange(*f.rangeFlag)
+	if err != nil {
+		return exit.Usage, fmt.Errorf("invalid -range: %v", err)
+	}
+	header, exists, err := eventColumns(path)
+	if err != nil {
+		return exit.Input, fmt.Errorf("%s: %w", path, err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	p := newPrompter(ctx, os.Stdin, os.Stdout)
+	if exists {
+		fmt.Printf("Adding events to %s. Press Ctrl-C to stop.\n", path)
+	} else {
+		fmt.Printf("Creating %s. Press Ctrl-C to stop.\n", path)
+	}
+
+	added := 0
+	abandon := func() (int, error) {
+		fmt.Printf("\nStopped; %d event(s) added to %s.\n", added, path)
+		return exit.OK, nil
+	}
+	for {
+		row, err := askEvent(p, header, lo, hi)
+		if errors.Is(err, errAbandoned) {
+			return abandon()
+		} else if err != nil {
+			return exit.Input, err
+		}
+		if err := appendEvent(path, row); err != nil {
+			return exit.Render, err
+		}
+		added++
+		more, err := p.yesNo("Add another?", true)
+		if errors.Is(err, errAbandoned) {
+			return abandon()
+		}
+		if !more {
+			break
+		}
+	}
+	fmt.Printf("%d event(s) added to %s.\n", added, path)
+
+	draw, err := p.yesNo("Render the chart now?", true)
+	if err != nil || !draw {
+		return exit.OK, nil
+	}
+	image, err := p.ask(fmt.Sprintf("Image file [%s]?", defaultOutput(path)), func(s string) error {
+		if ext := strings.ToLower(filepath.Ext(s)); s != "" && ext != ".png" && ext != ".svg" {
+			return errors.New("use a .png or .svg file name")
+		}
+		return nil
+	})
+	if err != nil {
+		return exit.OK, nil
+	}
+	if image == "" {
+		image = defaultOutput(path)
+	}
+	if _, err := os.Stat(image); err == nil {
+		replace, err := p.yesNo(image+" exists; replace it?", false)
+		if err != nil || !replace {
+			return exit.OK, nil
+		}
+	}
+	stop()
+	return run([]string{"-force", path, image})
+}
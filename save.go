@@ -0,0 +1,76 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gonum.org/v1/plot"
+	"gonum.org/v1/plot/vg"
+
+	"github.com/rojaswestall/lifeline/internal/render"
+)
+
+// savePlot renders p onto a w×h canvas and writes it to file, using the
+// file extension as the format. A positive margin leaves that much
+// whitespace between the canvas edge and everything the plot draws, and
+// gray renders every color as a shade of grey. A footer, if any, is drawn
+// under the plot. The image is stamped with the build that drew it.
+func savePlot(p *plot.Plot, w, h, margin vg.Length, gray bool, footer *render.Footer, file string) error {
+	c, err := render.Canvas(p, w, h, margin, gray, footer, file)
+	if err != nil {
+		return err
+	}
+	return writeImage(c, file)
+}
+
+// writeImage encodes c and writes it to file, stamped with the build that
+// drew it.
+func writeImage(c vg.CanvasWriterTo, file string) error {
+	var buf bytes.Buffer
+	if _, err := c.WriteTo(&buf); err != nil {
+		return err
+	}
+	format := strings.TrimPrefix(strings.ToLower(filepath.Ext(file)), ".")
+	return os.WriteFile(file, stampImage(buf.Bytes(), format, currentBuild()), 0o644)
+}
+
+// checkOverwrite refuses to replace an existing file unless force or
+// backup is set.
+func checkOverwrite(file string, force, backup bool) error {
+	_, err := os.Stat(file)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		return nil
+	case err != nil:
+		return err
+	case force || backup:
+		return nil
+	}
+	return fmt.Errorf("%s already exists; use -force to overwrite it or -backup to keep a copy", file)
+}
+
+// backupFile renames an existing file to file.bak, replacing any older
+// backup.
+func backupFile(file string) error {
+	err := os.Rename(file, file+".bak")
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+// checkPlot renders and encodes p like savePlot but throws the result
+// away, for -dry-run.
+func checkPlot(p *plot.Plot, w, h, margin vg.Length, gray bool, footer *render.Footer, file string) error {
+	c, err := render.Canvas(p, w, h, margin, gray, footer, file)
+	if err != nil {
+		return err
+	}
+	_, err = c.WriteTo(io.Discard)
+	return err
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+	"text/template"
+	"unicode"
+
+	"github.com/rojaswestall/lifeline/internal/timeline"
+)
+
+// splitTitleData is what a -split-title template can use.
+type splitTitleData struct {
+	Title    string // the -title text
+	Category string
+}
+
+// parseSplitTitle parses the -split-title template and tries it once so
+// mistakes surface before any chart is drawn.
+func parseSplitTitle(s string) (*template.Template, error) {
+	t, err := template.New("split-title").Option("missingkey=error").Parse(s)
+	if err != nil {
+		return nil, err
+	}
+	if err := t.Execute(&strings.Builder{}, splitTitleData{}); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// splitTitle is the title of the chart for one category.
+func splitTitle(t *template.Template, title, category string) string {
+	var b strings.Builder
+	if err := t.Execute(&b, splitTitleData{Title: title, Category: category}); err != nil {
+		return title
+	}
+	return b.String()
+}
+
+// categoryFileName turns a category into a file name, replacing anything
+// but letters, digits, '-' and '_' with '_'.
+func categoryFileName(name string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || r == '-' || r == '_' {
+			return r
+		}
+		return '_'
+	}, name)
+}
+
+// splitOutput is the file in dir for the chart of category, or for the
+// combined chart when category is "".
+func splitOutput(dir, category, format string) string {
+	file := "all"
+	if category != "" {
+		file = categoryFileName(category)
+		if file == "all" {
+			file = "all_category" // keep clear of the combined chart
+		}
+	}
+	return filepath.Join(dir, file+"."+format)
+}
+
+// pointsInCategory returns a copy of the points in the named category.
+func pointsInCategory(pts []timeline.Point, name string) []timeline.Point {
+	var out []timeline.Point
+	for _, p := range pts {
+		if p.Category == name {
+			out = append(out, p)
+		}
+	}
+	return out
+}
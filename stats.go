@@ -0,0 +1,215 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/rojaswestall/lifeline/internal/exit"
+	"github.com/rojaswestall/lifeline/internal/timeline"
+)
+
+// statEvent is one event as reported by the stats subcommand.
+type statEvent struct {
+	Year  float64 `json:"year"`
+	Value float64 `json:"value"`
+	Label string  `json:"label"`
+}
+
+// statChange is the change in value between two consecutive events.
+type statChange struct {
+	From   statEvent `json:"from"`
+	To     statEvent `json:"to"`
+	Change float64   `json:"change"`
+}
+
+// statStreak is a run of events on one side of zero, as -streaks shades.
+type statStreak struct {
+	FirstYear float64 `json:"first_year"`
+	LastYear  float64 `json:"last_year"`
+	Years     float64 `json:"years"`
+	Events    int     `json:"events"`
+}
+
+// stats summarizes a timeline for the stats subcommand.
+type stats struct {
+	Count          int                     `json:"count"`
+	FirstYear      float64                 `json:"first_year"`
+	LastYear       float64                 `json:"last_year"`
+	Mean           float64                 `json:"mean"`
+	Median         float64                 `json:"median"`
+	Best           statEvent               `json:"best"`
+	Worst          statEvent               `json:"worst"`
+	Polarity       timeline.PolarityCounts `json:"polarity"`
+	PositiveStreak int                     `json:"longest_positive_streak"`
+	BiggestRise    *statChange             `json:"biggest_rise,omitempty"`
+	BiggestFall    *statChange             `json:"biggest_fall,omitempty"`
+	LongestGood    *statStreak             `json:"longest_good_streak,omitempty"`
+	LongestBad     *statStreak             `json:"longest_bad_streak,omitempty"`
+	// Scale notes 0-100 data and whether it was rescaled.
+	Scale string `json:"scale,omitempty"`
+}
+
+// computeStats summarizes chronologically sorted points. Ties go to the
+// earliest event.
+func computeStats(pts []timeline.Point) stats {
+	ev := func(p timeline.Point) statEvent { return statEvent{Year: p.Year, Value: p.Value, Label: p.Label} }
+	s := stats{
+		Count:     len(pts),
+		FirstYear: pts[0].Year,
+		LastYear:  pts[len(pts)-1].Year,
+		Mean:      timeline.Mean(pts),
+		Polarity:  timeline.CountPolarity(pts),
+		Best:      ev(pts[0]),
+		Worst:     ev(pts[0]),
+	}
+
+	vals := make([]float64, len(pts))
+	streak := 0
+	for i, p := range pts {
+		vals[i] = p.Value
+		if p.Value > s.Best.Value {
+			s.Best = ev(p)
+		}
+		if p.Value < s.Worst.Value {
+			s.Worst = ev(p)
+		}
+		if p.Polarity() == timeline.Positive {
+			streak++
+			s.PositiveStreak = max(s.PositiveStreak, streak)
+		} else {
+			streak = 0
+		}
+		if i == 0 {
+			continue
+		}
+		d := p.Value - pts[i-1].Value
+		change := &statChange{From: ev(pts[i-1]), To: ev(p), Change: d}
+		if d > 0 && (s.BiggestRise == nil || d > s.BiggestRise.Change) {
+			s.BiggestRise = change
+		}
+		if d < 0 && (s.BiggestFall == nil || d < s.BiggestFall.Change) {
+			s.BiggestFall = change
+		}
+	}
+	sort.Float64s(vals)
+	s.Median = timeline.Percentile(vals, 0.5)
+
+	streaks := timeline.FindStreaks(pts, 0)
+	for _, pol := range []timeline.Polarity{timeline.Positive, timeline.Negative} {
+		st, ok := timeline.LongestStreak(streaks, pts, pol)
+		if !ok {
+			continue
+		}
+		ss := &statStreak{FirstYear: pts[st.First].Year, LastYear: pts[st.Last].Year, Years: timeline.StreakYears(pts, st), Events: st.Last - st.First + 1}
+		if pol == timeline.Positive {
+			s.LongestGood = ss
+		} else {
+			s.LongestBad = ss
+		}
+	}
+	return s
+}
+
+// statsFlags are the flags of the stats subcommand.
+type statsFlags struct {
+	fs        *flag.FlagSet
+	asJSON    *bool
+	autoScale *bool
+	months    *bool
+}
+
+// newStatsFlags defines the stats flags on a new FlagSet.
+func newStatsFlags() statsFlags {
+	fs := flag.NewFlagSet("stats", flag.ContinueOnError)
+	return statsFlags{
+		fs:        fs,
+		asJSON:    fs.Bool("json", false, "print the report as JSON"),
+		autoScale: fs.Bool("auto-scale", false, "when every value is between 0 and 100, map 0:100 onto -10:10 first"),
+		months:    fs.Bool("fractional-as-month", false, "show fractional years such as 2018.5 as months (\"Jul 2018\")"),
+	}
+}
+
+// runStats implements `lifeline stats [-json] input.csv`.
+func runStats(args []string) (int, error) {
+	f := newStatsFlags()
+	fs := f.fs
+	if err := parseFlags(fs, args); err != nil {
+		return flagStatus(err)
+	}
+	if fs.NArg() != 1 {
+		return exit.Usage, errors.New("stats takes one input file")
+	}
+
+	points, err := timeline.Load(fs.Arg(0))
+	if err != nil {
+		return exit.Input, err
+	}
+	if len(points) == 0 {
+		return exit.Input, errors.New("no data points")
+	}
+	scale := ""
+	if timeline.LooksLikePercent(points) {
+		scale = "0-100 scores; -auto-scale maps them onto -10:10"
+		if *f.autoScale {
+			timeline.PercentScale.Apply(points)
+			scale = "rescaled " + timeline.PercentScale.String()
+		}
+	}
+	if *f.months || scale != "" {
+		for i := range points {
+			if !points[i].Tagged {
+				points[i].Label = timeline.AutoLabel(points[i].Year, points[i].Value, *f.months)
+			}
+		}
+	}
+	s := computeStats(points)
+	s.Scale = scale
+
+	if *f.asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(s); err != nil {
+			return exit.Render, err
+		}
+		return exit.OK, nil
+	}
+
+	year := func(y float64) string { return timeline.FormatYear(y, *f.months) }
+	event := func(e statEvent) string { return fmt.Sprintf("%s (%+g) %s", year(e.Year), e.Value, e.Label) }
+	fmt.Printf("Events:          %d\n", s.Count)
+	fmt.Printf("Years:           %s–%s (%g years)\n", year(s.FirstYear), year(s.LastYear), s.LastYear-s.FirstYear)
+	if s.Scale != "" {
+		fmt.Printf("Scale:           %s\n", s.Scale)
+	}
+	fmt.Printf("Mean value:      %.2f\n", s.Mean)
+	fmt.Printf("Median value:    %.2f\n", s.Median)
+	fmt.Printf("Best:            %s\n", event(s.Best))
+	fmt.Printf("Worst:           %s\n", event(s.Worst))
+	fmt.Printf("Polarity:        %s\n", s.Polarity)
+	fmt.Printf("Positive streak: %d events\n", s.PositiveStreak)
+	for _, st := range []struct {
+		name string
+		s    *statStreak
+	}{{"Good streak", s.LongestGood}, {"Bad streak", s.LongestBad}} {
+		if st.s == nil {
+			fmt.Printf("%-16s none\n", st.name+":")
+			continue
+		}
+		fmt.Printf("%-16s %g years (%s–%s, %d events)\n", st.name+":", st.s.Years, year(st.s.FirstYear), year(st.s.LastYear), st.s.Events)
+	}
+	for _, c := range []struct {
+		name string
+		c    *statChange
+	}{{"Biggest rise", s.BiggestRise}, {"Biggest fall", s.BiggestFall}} {
+		if c.c == nil {
+			fmt.Printf("%-16s none\n", c.name+":")
+			continue
+		}
+		fmt.Printf("%-16s %+g from %s to %s\n", c.name+":", c.c.Change, event(c.c.From), event(c.c.To))
+	}
+	return exit.OK, nil
+}
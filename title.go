@@ -0,0 +1,66 @@
+package main
+
+import (
+	"math"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/rojaswestall/lifeline/internal/timeline"
+)
+
+// titleData is what -title and -footer templates can use, such as
+// "{{.Count}} events through {{.LastYear}}".
+type titleData struct {
+	Count               int    // events in the chart
+	FirstYear, LastYear string // as -fractional-as-month would show them
+	RenderDate          renderDate
+	Input               string // the input file as given
+}
+
+// renderDate prints as month and year ("Feb 2025"); other layouts are
+// available through Format, as in {{.RenderDate.Format "2006-01-02"}}.
+type renderDate struct{ time.Time }
+
+func (d renderDate) String() string { return d.Format("Jan 2006") }
+
+// newTitleData describes pts, the events of one chart, read from input.
+func newTitleData(pts []timeline.Point, input string, months bool, now time.Time) titleData {
+	d := titleData{Count: len(pts), RenderDate: renderDate{now}, Input: input}
+	if len(pts) == 0 {
+		return d
+	}
+	first, last := math.Inf(1), math.Inf(-1)
+	for _, p := range pts {
+		first = math.Min(first, p.Year)
+		last = math.Max(last, math.Max(p.Year, p.End))
+	}
+	d.FirstYear, d.LastYear = timeline.FormatYear(first, months), timeline.FormatYear(last, months)
+	return d
+}
+
+// parseTitleTemplate parses the text of the flag name as a template and
+// runs it once on sample data, so unknown placeholders are reported
+// before anything is drawn. A literal "{{" is written {{"{{"}}.
+func parseTitleTemplate(name, s string) (*template.Template, error) {
+	t, err := template.New(name).Parse(s)
+	if err != nil {
+		return nil, err
+	}
+	sample := titleData{Count: 1, FirstYear: "2000", LastYear: "2000", RenderDate: renderDate{time.Now()}}
+	if err := t.Execute(&strings.Builder{}, sample); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// expandTitle fills in t with d. Templates have been tried by
+// parseTitleTemplate, so an error here is unexpected and leaves the text
+// as it was written.
+func expandTitle(t *template.Template, d titleData) string {
+	var b strings.Builder
+	if err := t.Execute(&b, d); err != nil {
+		return t.Root.String()
+	}
+	return b.String()
+}
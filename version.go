@@ -0,0 +1,89 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"runtime/debug"
+)
+
+// Build information, set at release time with
+//
+//	go build -ldflags "-X main.version=1.4.0 -X main.commit=$(git rev-parse --short HEAD) -X main.buildDate=$(date -u +%Y-%m-%d)"
+//
+// Without them, commit and buildDate come from the VCS information Go
+// records in the binary, when there is any.
+var (
+	version   = "dev"
+	commit    = "dev"
+	buildDate = "dev"
+)
+
+// buildInfo describes this binary: its version, commit and build date,
+// and the gonum/plot version it was compiled against.
+type buildInfo struct {
+	Version, Commit, Date, Plot string
+}
+
+// currentBuild fills in buildInfo from the ldflags variables, falling back
+// on the binary's embedded build information.
+func currentBuild() buildInfo {
+	b := buildInfo{Version: version, Commit: commit, Date: buildDate, Plot: "unknown"}
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return b
+	}
+	if b.Version == "dev" && info.Main.Version != "" && info.Main.Version != "(devel)" {
+		b.Version = info.Main.Version
+	}
+	for _, s := range info.Settings {
+		switch {
+		case s.Key == "vcs.revision" && b.Commit == "dev":
+			b.Commit = s.Value
+			if len(b.Commit) > 12 {
+				b.Commit = b.Commit[:12]
+			}
+		case s.Key == "vcs.time" && b.Date == "dev":
+			b.Date = s.Value
+		}
+	}
+	for _, dep := range info.Deps {
+		if dep.Path == "gonum.org/v1/plot" {
+			b.Plot = dep.Version
+		}
+	}
+	return b
+}
+
+// String is the one-line form stamped into rendered images.
+func (b buildInfo) String() string {
+	return fmt.Sprintf("lifeline %s (commit %s, built %s, gonum.org/v1/plot %s)", b.Version, b.Commit, b.Date, b.Plot)
+}
+
+// stampImage records b in an encoded image: as a comment after the XML
+// declaration of an SVG, or as a Software text chunk after the header of
+// a PNG. Other data is returned unchanged.
+func stampImage(data []byte, format string, b buildInfo) []byte {
+	switch format {
+	case "svg":
+		i := 0
+		if bytes.HasPrefix(data, []byte("<?xml")) {
+			i = bytes.IndexByte(data, '\n') + 1
+		}
+		comment := fmt.Sprintf("<!-- %s -->\n", bytes.ReplaceAll([]byte(b.String()), []byte("--"), []byte("- -")))
+		return append(data[:i:i], append([]byte(comment), data[i:]...)...)
+	case "png":
+		// The 8-byte signature is followed by the 25-byte IHDR chunk.
+		const afterIHDR = 8 + 25
+		if len(data) < afterIHDR || string(data[12:16]) != "IHDR" {
+			return data
+		}
+		body := append([]byte("tEXtSoftware\x00"), b.String()...)
+		chunk := binary.BigEndian.AppendUint32(nil, uint32(len(body)-4))
+		chunk = append(chunk, body...)
+		chunk = binary.BigEndian.AppendUint32(chunk, crc32.ChecksumIEEE(body))
+		return append(data[:afterIHDR:afterIHDR], append(chunk, data[afterIHDR:]...)...)
+	}
+	return data
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+
+	"github.com/rojaswestall/lifeline/internal/exit"
+	"github.com/rojaswestall/lifeline/internal/logging"
+	"github.com/rojaswestall/lifeline/internal/timeline"
+)
+
+// watchDebounce is how long the watched files must stay quiet before a
+// render, so an editor's burst of writes on save draws the chart once.
+const watchDebounce = 200 * time.Millisecond
+
+// watching is set while -watch re-renders, so those runs draw the chart
+// instead of starting another watch.
+var watching bool
+
+// watchedFiles are the files a render reads: the input, the CSVs named by
+// -chapters, -baseline and -band, and the config file in use.
+func watchedFiles(input, chapters, baseline, band, config string) []string {
+	files := []string{input}
+	for _, f := range []string{chapters, baseline} {
+		if f != "" {
+			files = append(files, f)
+		}
+	}
+	if band != "" {
+		if more, err := timeline.BandFiles(band); err == nil {
+			files = append(files, more...)
+		}
+	}
+	if path, err := findConfig(config); err == nil && path != "" {
+		files = append(files, path)
+	}
+	return files
+}
+
+// watchRender renders with args, then again each time one of files
+// changes, until interrupted. Renders that fail are reported and the
+// watch goes on, so a mistake can be fixed and saved again.
+func watchRender(args []string, files []string) (int, error) {
+	w, err := fsnotify.NewWatcher()
+	if err != nil {
+		return exit.Render, err
+	}
+	defer w.Close()
+
+	// Editors often save by writing a new file and renaming it over the
+	// old one, which ends a watch on the file itself, so the directories
+	// are watched and events filtered by name.
+	wanted := make(map[string]bool)
+	for _, f := range files {
+		abs, err := filepath.Abs(f)
+		if err != nil {
+			return exit.Input, err
+		}
+		wanted[abs] = true
+		if err := w.Add(filepath.Dir(abs)); err != nil {
+			return exit.Input, err
+		}
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	watching = true
+	defer func() { watching = false }()
+	forced := false
+	render := func() {
+		code, err := run(args)
+		stamp := time.Now().Format("15:04:05")
+		if err != nil {
+			logging.ReportError(err)
+		}
+		if code == exit.OK {
+			logging.Notef("[%s] rendered\n", stamp)
+		} else {
+			logging.Notef("[%s] render failed (exit status %d); waiting for changes\n", stamp, code)
+		}
+		// Once a render has written the output, later ones replace it.
+		// Until then an existing output is left alone as usual.
+		if err == nil && !forced {
+			args = append([]string{"-force"}, args...)
+			forced = true
+		}
+	}
+	render()
+
+	var debounce <-chan time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			logging.Notef("\n")
+			return exit.OK, nil
+		case ev, ok := <-w.Events:
+			if !ok {
+				return exit.OK, nil
+			}
+			if ev.Has(fsnotify.Chmod) {
+				continue
+			}
+			if abs, err := filepath.Abs(ev.Name); err == nil && wanted[abs] {
+				logging.Logf("%s changed\n", ev.Name)
+				debounce = time.After(watchDebounce)
+			}
+		case err, ok := <-w.Errors:
+			if !ok {
+				return exit.OK, nil
+			}
+			logging.Warnf("watching files: %v", err)
+		case <-debounce:
+			debounce = nil
+			render()
+		}
+	}
+}